@@ -10,21 +10,33 @@ import (
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/widget"
 )
 
 var forceticks int = 0
+var layoutRunning bool
 
 func forceanim(diagramWidget *diagramwidget.DiagramWidget) {
 
 	for {
-		if forceticks > 0 {
-			fyne.Do(func() {
-				diagramwidget.StepForceLayout(diagramWidget, 300)
-				diagramWidget.Refresh()
-			})
+		fyne.Do(func() {
+			if forceticks == 0 {
+				return
+			}
+			// the whole run of the layout is a single step for undo
+			if !layoutRunning {
+				diagramWidget.StartCommandGroup("Layout")
+				layoutRunning = true
+			}
+			diagramwidget.StepForceLayout(diagramWidget, 300)
+			diagramWidget.Refresh()
 			forceticks--
-		}
+			if forceticks == 0 {
+				diagramWidget.EndCommandGroup()
+				layoutRunning = false
+			}
+		})
 
 		time.Sleep(time.Millisecond * (1000 / 30))
 	}
@@ -147,6 +159,11 @@ func main() {
 	link5.AddMidpointAnchoredText("linkName", "Link 5")
 	link5.AddTargetDecoration(diagramwidget.NewArrowhead())
 
+	w.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: fyne.KeyModifierShortcutDefault},
+		func(fyne.Shortcut) { diagramWidget.Undo() })
+	w.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyY, Modifier: fyne.KeyModifierShortcutDefault},
+		func(fyne.Shortcut) { diagramWidget.Redo() })
+
 	w.SetContent(scrollContainer)
 
 	w.Resize(fyne.NewSize(600, 400))
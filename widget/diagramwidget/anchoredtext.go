@@ -24,7 +24,9 @@ type AnchoredText struct {
 	referencePosition    fyne.Position
 	displayedTextBinding binding.String
 	ForegroundColor      color.Color
-	textEntry            *widget.Entry
+	textEntry            *anchoredTextEntry
+	// text is the last value of the binding, used to record edits
+	text string
 }
 
 // NewAnchoredText creates an textual annotation for a link. After it is created, one of the
@@ -35,10 +37,11 @@ func NewAnchoredText(text string) *AnchoredText {
 		offset:            r2.MakeVec2(0, 0),
 		ForegroundColor:   theme.Color(theme.ColorNameForeground),
 		referencePosition: fyne.Position{X: 0, Y: 0},
+		text:              text,
 	}
 	at.displayedTextBinding = binding.NewString()
 	at.displayedTextBinding.Set(text)
-	at.textEntry = widget.NewEntryWithData(at.displayedTextBinding)
+	at.textEntry = newAnchoredTextEntry(at)
 	at.displayedTextBinding.AddListener(at)
 	at.textEntry.Wrapping = fyne.TextWrapOff
 	at.textEntry.Scroll = container.ScrollNone
@@ -57,8 +60,18 @@ func (at *AnchoredText) CreateRenderer() fyne.WidgetRenderer {
 	return atr
 }
 
-// DataChanged is the callback function for the displayedTextBinding.
+// DataChanged is the callback function for the displayedTextBinding. It records a command for the
+// edit when the anchored text belongs to a link.
 func (at *AnchoredText) DataChanged() {
+	text, _ := at.displayedTextBinding.Get()
+	if text != at.text {
+		before := at.text
+		at.text = text
+		if at.link != nil {
+			at.link.diagram.RecordCommand(&textCommand{text: at, before: before, after: text,
+				session: at.link.diagram.textEditSession})
+		}
+	}
 	at.Refresh()
 }
 
@@ -87,7 +100,7 @@ func (at *AnchoredText) GetDisplayedTextBinding() binding.String {
 
 // GetTextEntry returns the entry widget
 func (at *AnchoredText) GetTextEntry() *widget.Entry {
-	return at.textEntry
+	return &at.textEntry.Entry
 }
 
 // MinSize returns the size of the entry widget plus a one-pixel border
@@ -110,6 +123,19 @@ func (at *AnchoredText) MouseMoved(event *desktop.MouseEvent) {
 func (at *AnchoredText) MouseOut() {
 }
 
+// endTextEdit starts a new edit session, so that the next change to the text is a separate step in the history
+func (at *AnchoredText) endTextEdit() {
+	if at.link != nil {
+		at.link.diagram.endTextEdit()
+	}
+}
+
+// setText changes the displayed text without recording the change
+func (at *AnchoredText) setText(text string) {
+	at.text = text
+	at.displayedTextBinding.Set(text)
+}
+
 // Move overrides the BaseWidget's Move method. It updates the anchored text's offset
 // and then calls the normal BaseWidget.Move method.
 func (at *AnchoredText) Move(position fyne.Position) {
@@ -162,3 +188,28 @@ func (atr *anchoredTextRenderer) Refresh() {
 	atr.widget.textEntry.Move(fyne.NewPos(5, 5))
 	atr.widget.textEntry.Refresh()
 }
+
+// anchoredTextEntry is the entry of an AnchoredText. The changes made while it has the focus are a single edit.
+type anchoredTextEntry struct {
+	widget.Entry
+	anchoredText *AnchoredText
+}
+
+func newAnchoredTextEntry(at *AnchoredText) *anchoredTextEntry {
+	entry := &anchoredTextEntry{anchoredText: at}
+	entry.ExtendBaseWidget(entry)
+	entry.Bind(at.displayedTextBinding)
+	return entry
+}
+
+// FocusGained starts a new edit of the text
+func (e *anchoredTextEntry) FocusGained() {
+	e.anchoredText.endTextEdit()
+	e.Entry.FocusGained()
+}
+
+// FocusLost ends the edit of the text
+func (e *anchoredTextEntry) FocusLost() {
+	e.anchoredText.endTextEdit()
+	e.Entry.FocusLost()
+}
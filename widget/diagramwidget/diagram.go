@@ -45,8 +45,22 @@ type DiagramWidget struct {
 	primarySelection               DiagramElement
 	selection                      map[string]DiagramElement
 	diagramElementLinkDependencies map[string][]linkPadPair
+	nodeFactories                  map[string]DiagramNodeFactory
+	linkFactories                  map[string]DiagramLinkFactory
+	undoStack                      []DiagramCommand
+	redoStack                      []DiagramCommand
+	currentGroup                   *commandGroup
+	groupDepth                     int
+	dragGroupOpen                  bool
+	replayingCommand               bool
+	textEditSession                int // changed when an edit of an AnchoredText ends, so later edits are not merged into it
 	// ConnectionTransaction holds transient data during the creation of a link. It is public for testing purposes
 	ConnectionTransaction *ConnectionTransaction
+	// HistoryLimit is the maximum number of steps that can be undone, the oldest steps are discarded
+	// when it is exceeded. Zero or less means there is no limit. Defaults to 100
+	HistoryLimit int
+	// CommandHistoryChangedCallback is called when a command is recorded, undone or redone, or the history is cleared
+	CommandHistoryChangedCallback func()
 	// IsConnectionAllowedCallback is called to determine whether a particular connection between a link and a pad is allowed
 	IsConnectionAllowedCallback func(DiagramLink, LinkEnd, ConnectionPad) bool
 	// LinkConnectionChangedCallback is called when a link connection changes. The string can either be
@@ -90,6 +104,7 @@ func NewDiagramWidget(id string) *DiagramWidget {
 		// Links:                          map[string]DiagramLink{},
 		selection:                      map[string]DiagramElement{},
 		diagramElementLinkDependencies: map[string][]linkPadPair{},
+		HistoryLimit:                   100,
 	}
 	dw.drawingArea = newDrawingArea(dw)
 	dw.drawingArea.Resize(dw.DesiredSize)
@@ -116,7 +131,7 @@ func (dw *DiagramWidget) addElementToSelection(de DiagramElement) {
 	if !dw.IsSelected(de) {
 		if dw.primarySelection == nil {
 			dw.primarySelection = de
-			dw.BringToFront(de.GetDiagramElementID())
+			dw.bringToFront(de.GetDiagramElementID())
 			if dw.PrimaryDiagramElementSelectionChangedCallback != nil {
 				dw.PrimaryDiagramElementSelectionChangedCallback(de.GetDiagramElementID())
 			}
@@ -208,6 +223,10 @@ func (dw *DiagramWidget) adjustBounds() {
 
 // BringToFront moves the diagram element to the top of the display list (which is the back of the DiagramElements list)
 func (dw *DiagramWidget) BringToFront(elementID string) {
+	dw.changeOrder("Bring to Front", elementID, func() { dw.bringToFront(elementID) })
+}
+
+func (dw *DiagramWidget) bringToFront(elementID string) {
 	for listElement := dw.DiagramElements.Front(); listElement != nil; listElement = listElement.Next() {
 		value := listElement.Value
 		diagramElement := value.(DiagramElement)
@@ -220,14 +239,16 @@ func (dw *DiagramWidget) BringToFront(elementID string) {
 
 // BringForward moves the diagram element on top of the next element of the display list
 func (dw *DiagramWidget) BringForward(elementID string) {
-	for listElement := dw.DiagramElements.Front(); listElement != nil; listElement = listElement.Next() {
-		value := listElement.Value
-		diagramElement := value.(DiagramElement)
-		if diagramElement.GetDiagramElementID() == elementID {
-			dw.DiagramElements.MoveAfter(listElement, listElement.Next())
-			dw.drawingArea.Refresh()
+	dw.changeOrder("Bring Forward", elementID, func() {
+		for listElement := dw.DiagramElements.Front(); listElement != nil; listElement = listElement.Next() {
+			value := listElement.Value
+			diagramElement := value.(DiagramElement)
+			if diagramElement.GetDiagramElementID() == elementID && listElement.Next() != nil {
+				dw.DiagramElements.MoveAfter(listElement, listElement.Next())
+				dw.drawingArea.Refresh()
+			}
 		}
-	}
+	})
 }
 
 // CreateRenderer creates the renderer for the diagram
@@ -271,17 +292,37 @@ func (dw *DiagramWidget) DiagramElementTapped(de DiagramElement) {
 }
 
 // DiagramNodeDragged moves the indicated node and refreshes any links that may be attached
-// to it
+// to it. If the node is selected, all of the selected nodes are moved with it. The whole drag,
+// up to DiagramNodeDragEnd, is recorded as a single command.
 func (dw *DiagramWidget) DiagramNodeDragged(node *BaseDiagramNode, event *fyne.DragEvent) {
 	delta := fyne.Position{X: event.Dragged.DX, Y: event.Dragged.DY}
-	dw.DisplaceNode(node, delta)
+	nodes := []DiagramNode{node}
+	if dw.IsSelected(node) {
+		nodes = dw.getSelectedNodes()
+	}
+	dw.startDragGroup("Move")
+	dw.displaceNodes(nodes, delta)
+	dw.RecordCommand(&moveCommand{diagram: dw, nodes: nodes, delta: delta})
+}
+
+// DiagramNodeDragEnd ends the command recording the drag of a node
+func (dw *DiagramWidget) DiagramNodeDragEnd(node *BaseDiagramNode) {
+	dw.endDragGroup()
 }
 
 // DisplaceNode moves the indicated node, refreshes any links that may be attached
 // to it, and adjusts the bounds of the drawing area
 func (dw *DiagramWidget) DisplaceNode(node DiagramNode, delta fyne.Position) {
-	node.Move(node.Position().Add(delta))
-	dw.refreshDependentLinks(node)
+	nodes := []DiagramNode{node}
+	dw.displaceNodes(nodes, delta)
+	dw.RecordCommand(&moveCommand{diagram: dw, nodes: nodes, delta: delta})
+}
+
+func (dw *DiagramWidget) displaceNodes(nodes []DiagramNode, delta fyne.Position) {
+	for _, node := range nodes {
+		node.Move(node.Position().Add(delta))
+		dw.refreshDependentLinks(node)
+	}
 	dw.adjustBounds()
 }
 
@@ -366,6 +407,17 @@ func (dw *DiagramWidget) GetDiagramNodes() []DiagramNode {
 	return diagramNodes
 }
 
+// getSelectedNodes returns the selected nodes in display order
+func (dw *DiagramWidget) getSelectedNodes() []DiagramNode {
+	selectedNodes := []DiagramNode{}
+	for _, node := range dw.GetDiagramNodes() {
+		if dw.IsSelected(node) {
+			selectedNodes = append(selectedNodes, node)
+		}
+	}
+	return selectedNodes
+}
+
 // GetPrimarySelection returns the diagram element that is currently selected
 func (dw *DiagramWidget) GetPrimarySelection() DiagramElement {
 	return dw.primarySelection
@@ -450,26 +502,34 @@ func (dw *DiagramWidget) refreshDependentLinks(de DiagramElement) {
 
 // RemoveElement removes the element from the diagram. It also removes any linkss to the element
 func (dw *DiagramWidget) RemoveElement(elementID string) {
+	removed := dw.removeElement(elementID)
+	if len(removed) > 0 {
+		dw.RecordCommand(&removeCommand{diagram: dw, elementID: elementID, removed: removed})
+	}
+}
+
+// removeElement removes the element and the links to it, and returns what was removed in the order of removal
+func (dw *DiagramWidget) removeElement(elementID string) []removedElement {
 	element := dw.GetDiagramElement(elementID)
 	if element == nil {
-		return
+		return nil
 	}
+	var removed []removedElement
 	// We make a copy of the dependencies because the array can get modified during the iteration
 	currentDependencies := append([]linkPadPair(nil), dw.diagramElementLinkDependencies[elementID]...)
 	for _, pair := range currentDependencies {
-		dw.RemoveElement(pair.link.id)
+		removed = append(removed, dw.removeElement(pair.link.id)...)
 	}
 	delete(dw.diagramElementLinkDependencies, elementID)
-	for listElement := dw.DiagramElements.Front(); listElement != nil; listElement = listElement.Next() {
-		diagramElement := listElement.Value.(DiagramElement)
-		if diagramElement.GetDiagramElementID() == elementID {
-			dw.DiagramElements.Remove(listElement)
-		}
+	if listElement := dw.findListElement(elementID); listElement != nil {
+		removed = append(removed, removedElement{element: element, behind: elementBehind(listElement)})
+		dw.DiagramElements.Remove(listElement)
 	}
 	if element.IsLink() {
 		dw.removeDependenciesInvolvingLink(elementID)
 	}
 	dw.drawingArea.Refresh()
+	return removed
 }
 
 // SelectDiagramElement clears the selection, makes the indicated element the primary selection, and invokes
@@ -493,26 +553,30 @@ func (dw *DiagramWidget) SelectDiagramElementNoCallback(id string) {
 
 // SendToBack moves the diagram element to the top of the display list (which is the front of the DiagramElements list)
 func (dw *DiagramWidget) SendToBack(elementID string) {
-	for listElement := dw.DiagramElements.Front(); listElement != nil; listElement = listElement.Next() {
-		value := listElement.Value
-		diagramElement := value.(DiagramElement)
-		if diagramElement.GetDiagramElementID() == elementID {
-			dw.DiagramElements.MoveToFront(listElement)
-			dw.drawingArea.Refresh()
+	dw.changeOrder("Send to Back", elementID, func() {
+		for listElement := dw.DiagramElements.Front(); listElement != nil; listElement = listElement.Next() {
+			value := listElement.Value
+			diagramElement := value.(DiagramElement)
+			if diagramElement.GetDiagramElementID() == elementID {
+				dw.DiagramElements.MoveToFront(listElement)
+				dw.drawingArea.Refresh()
+			}
 		}
-	}
+	})
 }
 
 // SendBackward moves the diagram element on top of the next element of the display list
 func (dw *DiagramWidget) SendBackward(elementID string) {
-	for listElement := dw.DiagramElements.Front(); listElement != nil; listElement = listElement.Next() {
-		value := listElement.Value
-		diagramElement := value.(DiagramElement)
-		if diagramElement.GetDiagramElementID() == elementID {
-			dw.DiagramElements.MoveBefore(listElement, listElement.Prev())
-			dw.drawingArea.Refresh()
+	dw.changeOrder("Send Backward", elementID, func() {
+		for listElement := dw.DiagramElements.Front(); listElement != nil; listElement = listElement.Next() {
+			value := listElement.Value
+			diagramElement := value.(DiagramElement)
+			if diagramElement.GetDiagramElementID() == elementID && listElement.Prev() != nil {
+				dw.DiagramElements.MoveBefore(listElement, listElement.Prev())
+				dw.drawingArea.Refresh()
+			}
 		}
-	}
+	})
 }
 
 // showAllPads is a work-around for fyne Issue #3906 in which a child's Hoverable interface
@@ -0,0 +1,469 @@
+package diagramwidget
+
+import (
+	"container/list"
+	"slices"
+
+	"fyne.io/fyne/v2"
+)
+
+// DiagramCommand is a change to a diagram that has already been made and that can be undone and
+// redone. The DiagramWidget records commands for the changes made by the user and by its own
+// methods, and applications can record their own with RecordCommand.
+type DiagramCommand interface {
+	// Name describes the change, e.g. "Move", for use in menu items such as "Undo Move"
+	Name() string
+	// Undo reverts the change
+	Undo()
+	// Redo makes the change again after it has been undone
+	Redo()
+}
+
+// mergeableCommand is implemented by commands that can absorb a following command of the same kind,
+// so that a stream of small changes, such as the events of a drag, becomes a single step.
+type mergeableCommand interface {
+	// merge absorbs next into the receiver and returns true if it could. grouped is true
+	// when both commands are part of the same command group.
+	merge(next DiagramCommand, grouped bool) bool
+}
+
+// commandGroup is a sequence of commands that are undone and redone as a single step
+type commandGroup struct {
+	name     string
+	commands []DiagramCommand
+}
+
+func (cg *commandGroup) Name() string {
+	return cg.name
+}
+
+func (cg *commandGroup) Undo() {
+	for i := len(cg.commands) - 1; i >= 0; i-- {
+		cg.commands[i].Undo()
+	}
+}
+
+func (cg *commandGroup) Redo() {
+	for _, command := range cg.commands {
+		command.Redo()
+	}
+}
+
+// CanRedo returns true if there is an undone command that can be redone
+func (dw *DiagramWidget) CanRedo() bool {
+	return len(dw.redoStack) > 0
+}
+
+// CanUndo returns true if there is a command that can be undone
+func (dw *DiagramWidget) CanUndo() bool {
+	return len(dw.undoStack) > 0
+}
+
+// ClearHistory discards all of the commands that could be undone or redone
+func (dw *DiagramWidget) ClearHistory() {
+	dw.undoStack = nil
+	dw.redoStack = nil
+	dw.currentGroup = nil
+	dw.groupDepth = 0
+	dw.dragGroupOpen = false
+	dw.endTextEdit()
+	dw.historyChanged()
+}
+
+// EndCommandGroup ends the group started by the matching call to StartCommandGroup. When the outermost
+// group ends, the commands recorded since it started become a single step in the history.
+func (dw *DiagramWidget) EndCommandGroup() {
+	if dw.groupDepth == 0 {
+		return
+	}
+	dw.groupDepth--
+	if dw.groupDepth > 0 {
+		return
+	}
+	group := dw.currentGroup
+	dw.currentGroup = nil
+	switch len(group.commands) {
+	case 0:
+		return
+	case 1:
+		dw.pushCommand(group.commands[0])
+	default:
+		dw.pushCommand(group)
+	}
+}
+
+// RecordCommand adds a change that has already been made to the history, so that it can be undone. It
+// discards any commands that could be redone. Commands are not recorded while a command is being undone or redone.
+func (dw *DiagramWidget) RecordCommand(command DiagramCommand) {
+	if dw.replayingCommand {
+		return
+	}
+	if dw.currentGroup != nil {
+		dw.currentGroup.commands = appendCommand(dw.currentGroup.commands, command, true)
+		return
+	}
+	dw.pushCommand(command)
+}
+
+// Redo makes the most recently undone change again. It does nothing while a command group is open.
+func (dw *DiagramWidget) Redo() {
+	if !dw.CanRedo() || dw.currentGroup != nil {
+		return
+	}
+	command := dw.redoStack[len(dw.redoStack)-1]
+	dw.redoStack = dw.redoStack[:len(dw.redoStack)-1]
+	dw.replay(command.Redo)
+	dw.endTextEdit()
+	dw.undoStack = append(dw.undoStack, command)
+	dw.historyChanged()
+}
+
+// RedoName returns the name of the command that Redo would make again, or an empty string if there is none
+func (dw *DiagramWidget) RedoName() string {
+	if !dw.CanRedo() {
+		return ""
+	}
+	return dw.redoStack[len(dw.redoStack)-1].Name()
+}
+
+// StartCommandGroup starts collecting the commands that are recorded into a single step with the given name,
+// until the matching call to EndCommandGroup. Groups may be nested, in which case the outermost name is used.
+func (dw *DiagramWidget) StartCommandGroup(name string) {
+	if dw.groupDepth == 0 {
+		dw.currentGroup = &commandGroup{name: name}
+	}
+	dw.groupDepth++
+}
+
+// Undo reverts the most recent change. It does nothing while a command group is open.
+func (dw *DiagramWidget) Undo() {
+	if !dw.CanUndo() || dw.currentGroup != nil {
+		return
+	}
+	command := dw.undoStack[len(dw.undoStack)-1]
+	dw.undoStack = dw.undoStack[:len(dw.undoStack)-1]
+	dw.replay(command.Undo)
+	dw.endTextEdit()
+	dw.redoStack = append(dw.redoStack, command)
+	dw.historyChanged()
+}
+
+// UndoName returns the name of the command that Undo would revert, or an empty string if there is none
+func (dw *DiagramWidget) UndoName() string {
+	if !dw.CanUndo() {
+		return ""
+	}
+	return dw.undoStack[len(dw.undoStack)-1].Name()
+}
+
+// appendCommand adds the command to the list, merging it into the last one when possible. Within a group, moves
+// do not depend on each other, so a move can also be merged into an earlier move of the same nodes as long as
+// only moves follow it. This keeps a layout run, which moves each node in turn, to one command per node.
+func appendCommand(commands []DiagramCommand, command DiagramCommand, grouped bool) []DiagramCommand {
+	_, isMove := command.(*moveCommand)
+	for i := len(commands) - 1; i >= 0; i-- {
+		if previous, ok := commands[i].(mergeableCommand); ok && previous.merge(command, grouped) {
+			return commands
+		}
+		if _, previousIsMove := commands[i].(*moveCommand); !grouped || !isMove || !previousIsMove {
+			break
+		}
+	}
+	return append(commands, command)
+}
+
+// endTextEdit stops later text edits from being merged into the text command on top of the history
+func (dw *DiagramWidget) endTextEdit() {
+	dw.textEditSession++
+}
+
+// endDragGroup ends the group started by startDragGroup
+func (dw *DiagramWidget) endDragGroup() {
+	if dw.dragGroupOpen {
+		dw.dragGroupOpen = false
+		dw.EndCommandGroup()
+	}
+}
+
+func (dw *DiagramWidget) historyChanged() {
+	if dw.CommandHistoryChangedCallback != nil {
+		dw.CommandHistoryChangedCallback()
+	}
+}
+
+func (dw *DiagramWidget) pushCommand(command DiagramCommand) {
+	dw.undoStack = appendCommand(dw.undoStack, command, false)
+	if dw.HistoryLimit > 0 && len(dw.undoStack) > dw.HistoryLimit {
+		dw.undoStack = slices.Delete(dw.undoStack, 0, len(dw.undoStack)-dw.HistoryLimit)
+	}
+	dw.redoStack = nil
+	dw.historyChanged()
+}
+
+// replay runs the undo or redo of a command, or the loading of a diagram, without recording the changes that it makes
+func (dw *DiagramWidget) replay(f func()) {
+	dw.replayingCommand = true
+	defer func() { dw.replayingCommand = false }()
+	f()
+}
+
+// startDragGroup starts a command group on the first event of a drag, so that the whole drag is a single step
+func (dw *DiagramWidget) startDragGroup(name string) {
+	if !dw.dragGroupOpen {
+		dw.dragGroupOpen = true
+		dw.StartCommandGroup(name)
+	}
+}
+
+// moveCommand records the displacement of one or more nodes
+type moveCommand struct {
+	diagram *DiagramWidget
+	nodes   []DiagramNode
+	delta   fyne.Position
+}
+
+func (mc *moveCommand) Name() string {
+	return "Move"
+}
+
+func (mc *moveCommand) Undo() {
+	mc.diagram.displaceNodes(mc.nodes, fyne.Position{X: -mc.delta.X, Y: -mc.delta.Y})
+}
+
+func (mc *moveCommand) Redo() {
+	mc.diagram.displaceNodes(mc.nodes, mc.delta)
+}
+
+func (mc *moveCommand) merge(next DiagramCommand, grouped bool) bool {
+	nextMove, ok := next.(*moveCommand)
+	if !grouped || !ok || !slices.Equal(mc.nodes, nextMove.nodes) {
+		return false
+	}
+	mc.delta = mc.delta.Add(nextMove.delta)
+	return true
+}
+
+// nodeBounds is the part of a node's state that changes when it is resized
+type nodeBounds struct {
+	position  fyne.Position
+	size      fyne.Size
+	innerSize fyne.Size
+}
+
+func getNodeBounds(node *BaseDiagramNode) nodeBounds {
+	return nodeBounds{position: node.Position(), size: node.Size(), innerSize: node.InnerSize}
+}
+
+// resizeCommand records the resizing of a node with its handles
+type resizeCommand struct {
+	node          *BaseDiagramNode
+	before, after nodeBounds
+}
+
+func (rc *resizeCommand) Name() string {
+	return "Resize"
+}
+
+func (rc *resizeCommand) Undo() {
+	rc.apply(rc.before)
+}
+
+func (rc *resizeCommand) Redo() {
+	rc.apply(rc.after)
+}
+
+func (rc *resizeCommand) apply(bounds nodeBounds) {
+	rc.node.InnerSize = bounds.innerSize
+	rc.node.Resize(bounds.size)
+	rc.node.Move(bounds.position)
+	rc.node.diagram.refreshDependentLinks(rc.node)
+	rc.node.diagram.adjustBounds()
+}
+
+func (rc *resizeCommand) merge(next DiagramCommand, grouped bool) bool {
+	nextResize, ok := next.(*resizeCommand)
+	if !grouped || !ok || nextResize.node != rc.node {
+		return false
+	}
+	rc.after = nextResize.after
+	return true
+}
+
+// linkEndCommand records the reconnection of one end of a link. When the end is not connected to a pad,
+// the position of the end point, in diagram coordinates, is restored instead.
+type linkEndCommand struct {
+	link               *BaseDiagramLink
+	end                LinkEnd
+	oldPad, newPad     ConnectionPad
+	oldPoint, newPoint fyne.Position
+}
+
+func (lc *linkEndCommand) Name() string {
+	return "Reconnect"
+}
+
+func (lc *linkEndCommand) Undo() {
+	lc.link.setEndPad(lc.end, lc.newPad, lc.oldPad, lc.oldPoint)
+}
+
+func (lc *linkEndCommand) Redo() {
+	lc.link.setEndPad(lc.end, lc.oldPad, lc.newPad, lc.newPoint)
+}
+
+// orderCommand records the move of an element within the display list. It remembers the element that
+// was immediately behind it before and after the move, where nil means it was at the back of the list.
+type orderCommand struct {
+	diagram                   *DiagramWidget
+	name                      string
+	element                   DiagramElement
+	behindBefore, behindAfter DiagramElement
+}
+
+func (oc *orderCommand) Name() string {
+	return oc.name
+}
+
+func (oc *orderCommand) Undo() {
+	oc.diagram.placeInFrontOf(oc.element, oc.behindBefore)
+}
+
+func (oc *orderCommand) Redo() {
+	oc.diagram.placeInFrontOf(oc.element, oc.behindAfter)
+}
+
+// changeOrder makes a change to the position of an element in the display list and records it if the position changed
+func (dw *DiagramWidget) changeOrder(name string, elementID string, change func()) {
+	listElement := dw.findListElement(elementID)
+	if listElement == nil {
+		return
+	}
+	behindBefore := elementBehind(listElement)
+	change()
+	behindAfter := elementBehind(listElement)
+	if behindBefore != behindAfter {
+		dw.RecordCommand(&orderCommand{
+			diagram:      dw,
+			name:         name,
+			element:      listElement.Value.(DiagramElement),
+			behindBefore: behindBefore,
+			behindAfter:  behindAfter,
+		})
+	}
+}
+
+// elementBehind returns the diagram element immediately behind a display list entry, or nil if it is at the back
+func elementBehind(listElement *list.Element) DiagramElement {
+	if listElement.Prev() == nil {
+		return nil
+	}
+	return listElement.Prev().Value.(DiagramElement)
+}
+
+// findListElement returns the display list entry for the element with the ID, or nil if there is none
+func (dw *DiagramWidget) findListElement(elementID string) *list.Element {
+	for listElement := dw.DiagramElements.Front(); listElement != nil; listElement = listElement.Next() {
+		if listElement.Value.(DiagramElement).GetDiagramElementID() == elementID {
+			return listElement
+		}
+	}
+	return nil
+}
+
+// placeInFrontOf moves or adds the element to the display list immediately in front of another element.
+// If the other element is nil, or is no longer in the diagram, the element is placed at the back.
+func (dw *DiagramWidget) placeInFrontOf(element DiagramElement, behind DiagramElement) {
+	listElement := dw.findListElement(element.GetDiagramElementID())
+	if listElement == nil {
+		listElement = dw.DiagramElements.PushFront(element)
+	}
+	var behindListElement *list.Element
+	if behind != nil {
+		behindListElement = dw.findListElement(behind.GetDiagramElementID())
+	}
+	if behindListElement == nil {
+		dw.DiagramElements.MoveToFront(listElement)
+	} else if behindListElement != listElement {
+		dw.DiagramElements.MoveAfter(listElement, behindListElement)
+	}
+	dw.drawingArea.Refresh()
+}
+
+// removedElement is an element that has been removed from the diagram, together with the element that was
+// immediately behind it in the display list when it was removed
+type removedElement struct {
+	element DiagramElement
+	behind  DiagramElement
+}
+
+// removeCommand records the removal of an element and of the links that were removed with it
+type removeCommand struct {
+	diagram   *DiagramWidget
+	elementID string
+	removed   []removedElement
+}
+
+func (rc *removeCommand) Name() string {
+	return "Delete"
+}
+
+// Undo puts the elements back in the reverse of the order in which they were removed, so that
+// each one goes back in front of the element that was behind it, then reconnects the links.
+func (rc *removeCommand) Undo() {
+	dw := rc.diagram
+	for i := len(rc.removed) - 1; i >= 0; i-- {
+		dw.placeInFrontOf(rc.removed[i].element, rc.removed[i].behind)
+	}
+	for _, removed := range rc.removed {
+		if link, ok := removed.element.(DiagramLink); ok {
+			bdl := link.getBaseDiagramLink()
+			if bdl.sourcePad != nil {
+				dw.addLinkDependency(bdl.sourcePad.GetPadOwner(), bdl, bdl.sourcePad)
+			}
+			if bdl.targetPad != nil {
+				dw.addLinkDependency(bdl.targetPad.GetPadOwner(), bdl, bdl.targetPad)
+			}
+		}
+	}
+	for _, removed := range rc.removed {
+		if removed.element.IsLink() {
+			removed.element.Refresh()
+		}
+	}
+	dw.adjustBounds()
+}
+
+func (rc *removeCommand) Redo() {
+	for _, removed := range rc.removed {
+		rc.diagram.removeElementFromSelection(removed.element)
+	}
+	rc.removed = rc.diagram.removeElement(rc.elementID)
+}
+
+// textCommand records an edit to the text of an AnchoredText. Consecutive changes to the same text are
+// merged while its entry keeps the focus, until another command is recorded or a command is undone or redone.
+type textCommand struct {
+	text          *AnchoredText
+	before, after string
+	session       int // the textEditSession of the diagram when the edit was made
+}
+
+func (tc *textCommand) Name() string {
+	return "Edit Text"
+}
+
+func (tc *textCommand) Undo() {
+	tc.text.setText(tc.before)
+}
+
+func (tc *textCommand) Redo() {
+	tc.text.setText(tc.after)
+}
+
+func (tc *textCommand) merge(next DiagramCommand, _ bool) bool {
+	nextText, ok := next.(*textCommand)
+	if !ok || nextText.text != tc.text || nextText.session != tc.session {
+		return false
+	}
+	tc.after = nextText.after
+	return true
+}
@@ -0,0 +1,286 @@
+package diagramwidget
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagramWidget_UndoMove(t *testing.T) {
+	test.NewTempApp(t)
+	diagram := makeTestDiagram()
+	changes := 0
+	diagram.CommandHistoryChangedCallback = func() { changes++ }
+	node1 := diagram.GetDiagramNode("Node1")
+	node2 := diagram.GetDiagramNode("Node2")
+
+	diagram.DisplaceNode(node1, fyne.NewPos(10, 20))
+	assert.Equal(t, fyne.NewPos(110, 120), node1.Position())
+	assert.Equal(t, "Move", diagram.UndoName())
+	assert.Equal(t, 1, changes)
+
+	// a drag of a selected node moves the whole selection as a single step
+	diagram.ElementTappedExtendsSelection = true
+	diagram.DiagramElementTapped(node1)
+	diagram.DiagramElementTapped(node2)
+	for i := 0; i < 5; i++ {
+		node2.getBaseDiagramNode().Dragged(&fyne.DragEvent{Dragged: fyne.Delta{DX: 2, DY: 1}})
+	}
+	node2.getBaseDiagramNode().DragEnd()
+	assert.Equal(t, fyne.NewPos(120, 125), node1.Position())
+	assert.Equal(t, fyne.NewPos(310, 155), node2.Position())
+	assert.Equal(t, 2, changes)
+
+	diagram.Undo()
+	assert.Equal(t, fyne.NewPos(110, 120), node1.Position())
+	assert.Equal(t, fyne.NewPos(300, 150), node2.Position())
+	diagram.Undo()
+	assert.Equal(t, fyne.NewPos(100, 100), node1.Position())
+	assert.False(t, diagram.CanUndo())
+	assert.Equal(t, 4, changes)
+
+	diagram.Redo()
+	diagram.Redo()
+	assert.Equal(t, fyne.NewPos(120, 125), node1.Position())
+	assert.Equal(t, fyne.NewPos(310, 155), node2.Position())
+	assert.False(t, diagram.CanRedo())
+}
+
+func TestDiagramWidget_UndoResize(t *testing.T) {
+	test.NewTempApp(t)
+	diagram := makeTestDiagram()
+	node := diagram.GetDiagramNode("Node1").getBaseDiagramNode()
+	node.InnerSize = node.effectiveInnerSize()
+	before := getNodeBounds(node)
+
+	handle := node.handles["lowerRight"]
+	node.handleDragged(handle, &fyne.DragEvent{Dragged: fyne.Delta{DX: 10, DY: 5}})
+	node.handleDragged(handle, &fyne.DragEvent{Dragged: fyne.Delta{DX: 10, DY: 5}})
+	node.handleDragEnd(handle)
+	assert.Equal(t, before.innerSize.AddWidthHeight(20, 10), node.InnerSize)
+	after := getNodeBounds(node)
+
+	assert.Equal(t, "Resize", diagram.UndoName())
+	diagram.Undo()
+	assert.Equal(t, before, getNodeBounds(node))
+	assert.False(t, diagram.CanUndo())
+	diagram.Redo()
+	assert.Equal(t, after, getNodeBounds(node))
+}
+
+func TestDiagramWidget_UndoReconnect(t *testing.T) {
+	test.NewTempApp(t)
+	diagram := makeTestDiagram()
+	link := diagram.GetDiagramLink("Link2").getBaseDiagramLink()
+	node2 := diagram.GetDiagramNode("Node2")
+	reconnected := 0
+	diagram.LinkConnectionChangedCallback = func(DiagramLink, string, ConnectionPad, ConnectionPad) { reconnected++ }
+
+	handle := link.handles[TARGET.ToString()]
+	link.handleDragged(handle, &fyne.DragEvent{Dragged: fyne.Delta{DX: -5, DY: -5}})
+	diagram.ConnectionTransaction.PendingPad = node2.GetDefaultConnectionPad()
+	link.handleDragEnd(handle)
+	assert.Equal(t, node2.GetDefaultConnectionPad(), link.GetTargetPad())
+	assert.Equal(t, 1, reconnected)
+
+	assert.Equal(t, "Reconnect", diagram.UndoName())
+	diagram.Undo()
+	assert.Nil(t, link.GetTargetPad())
+	assert.Equal(t, fyne.NewPos(400, 400), link.Position().Add(link.GetLinkPoints()[1].Position()))
+	assert.Len(t, diagram.diagramElementLinkDependencies["Node2"], 1)
+	assert.Equal(t, 2, reconnected)
+
+	diagram.Redo()
+	assert.Equal(t, node2.GetDefaultConnectionPad(), link.GetTargetPad())
+	assert.Len(t, diagram.diagramElementLinkDependencies["Node2"], 2)
+}
+
+func TestDiagramWidget_UndoRemoveElement(t *testing.T) {
+	test.NewTempApp(t)
+	diagram := makeTestDiagram()
+	elements := diagram.GetDiagramElements()
+
+	diagram.RemoveElement("Node1")
+	assert.Len(t, diagram.GetDiagramElements(), 1)
+	assert.Equal(t, "Delete", diagram.UndoName())
+
+	diagram.Undo()
+	assert.Equal(t, elements, diagram.GetDiagramElements())
+	link := diagram.GetDiagramLink("Link1")
+	assert.Equal(t, diagram.GetDiagramNode("Node1").GetDefaultConnectionPad(), link.GetSourcePad())
+	assert.Len(t, diagram.diagramElementLinkDependencies["Node1"], 1)
+	assert.Len(t, diagram.diagramElementLinkDependencies["Link1"], 1)
+
+	diagram.Redo()
+	assert.Len(t, diagram.GetDiagramElements(), 1)
+	assert.Nil(t, diagram.GetDiagramLink("Link2"))
+}
+
+func TestDiagramWidget_UndoOrder(t *testing.T) {
+	test.NewTempApp(t)
+	diagram := makeTestDiagram()
+	// selecting brings an element to the front without adding to the history
+	diagram.SelectDiagramElement(diagram.GetDiagramNode("Node1"))
+	assert.False(t, diagram.CanUndo())
+	elements := diagram.GetDiagramElements()
+	assert.Equal(t, "Node1", elements[3].GetDiagramElementID())
+
+	diagram.SendToBack("Link2")
+	assert.Equal(t, "Link2", diagram.GetDiagramElements()[0].GetDiagramElementID())
+	assert.Equal(t, "Send to Back", diagram.UndoName())
+	diagram.Undo()
+	assert.Equal(t, elements, diagram.GetDiagramElements())
+
+	// moves that do not change the order are not recorded
+	diagram.BringForward("Node1")
+	diagram.SendBackward("Node2")
+	assert.False(t, diagram.CanUndo())
+	assert.True(t, diagram.CanRedo())
+	diagram.BringForward("Link2")
+	assert.Equal(t, "Bring Forward", diagram.UndoName())
+	assert.False(t, diagram.CanRedo())
+}
+
+func TestDiagramWidget_UndoTextEdit(t *testing.T) {
+	test.NewTempApp(t)
+	diagram := makeTestDiagram()
+	text := diagram.GetDiagramLink("Link1").getBaseDiagramLink().GetMidpointAnchoredText("name")
+
+	for _, value := range []string{"use", "us", "u", "has"} {
+		text.GetDisplayedTextBinding().Set(value)
+	}
+	assert.Equal(t, "Edit Text", diagram.UndoName())
+
+	diagram.Undo()
+	value, _ := text.GetDisplayedTextBinding().Get()
+	assert.Equal(t, "uses", value)
+	assert.False(t, diagram.CanUndo())
+	diagram.Redo()
+	value, _ = text.GetDisplayedTextBinding().Get()
+	assert.Equal(t, "has", value)
+}
+
+func TestDiagramWidget_UndoTextEditSessions(t *testing.T) {
+	test.NewTempApp(t)
+	diagram := makeTestDiagram()
+	text := diagram.GetDiagramLink("Link1").getBaseDiagramLink().GetMidpointAnchoredText("name")
+	binding := text.GetDisplayedTextBinding()
+
+	// an undo ends the edit, so the next edit is a separate step
+	binding.Set("used")
+	diagram.DisplaceNode(diagram.GetDiagramNode("Node1"), fyne.NewPos(10, 0))
+	diagram.Undo()
+	binding.Set("user")
+	diagram.Undo()
+	value, _ := binding.Get()
+	assert.Equal(t, "used", value)
+	diagram.Undo()
+	value, _ = binding.Get()
+	assert.Equal(t, "uses", value)
+	assert.False(t, diagram.CanUndo())
+
+	// so does the entry losing the focus
+	diagram.ClearHistory()
+	binding.Set("has")
+	text.textEntry.FocusLost()
+	binding.Set("owns")
+	assert.Len(t, diagram.undoStack, 2)
+}
+
+func TestDiagramWidget_CommandGroup(t *testing.T) {
+	test.NewTempApp(t)
+	diagram := makeTestDiagram()
+	node1 := diagram.GetDiagramNode("Node1")
+
+	diagram.StartCommandGroup("Tidy")
+	diagram.DisplaceNode(node1, fyne.NewPos(10, 0))
+	diagram.StartCommandGroup("Nested")
+	diagram.BringToFront("Node1")
+	diagram.EndCommandGroup()
+	diagram.Undo()
+	assert.Equal(t, fyne.NewPos(110, 100), node1.Position())
+	diagram.EndCommandGroup()
+
+	assert.Equal(t, "Tidy", diagram.UndoName())
+	diagram.Undo()
+	assert.Equal(t, fyne.NewPos(100, 100), node1.Position())
+	assert.Equal(t, "Node1", diagram.GetDiagramElements()[0].GetDiagramElementID())
+	assert.Equal(t, "Tidy", diagram.RedoName())
+
+	diagram.ClearHistory()
+	assert.False(t, diagram.CanRedo())
+}
+
+func TestDiagramWidget_UndoKeepsNewElements(t *testing.T) {
+	test.NewTempApp(t)
+	diagram := makeTestDiagram()
+
+	diagram.BringToFront("Node1")
+	NewDiagramNode(diagram, nil, "NodeNew")
+	diagram.Undo()
+	assert.NotNil(t, diagram.GetDiagramElement("NodeNew"))
+	assert.Equal(t, "Node1", diagram.GetDiagramElements()[0].GetDiagramElementID())
+	// the element goes back in front of the element that was behind it after the change
+	diagram.Redo()
+	assert.Equal(t, "Node1", diagram.GetDiagramElements()[3].GetDiagramElementID())
+
+	// links created after a delete keep their connections when the delete is undone
+	diagram.RemoveElement("Node2")
+	assert.Nil(t, diagram.GetDiagramElement("Link1"))
+	assert.Nil(t, diagram.GetDiagramElement("Link2"))
+	link := NewDiagramLink(diagram, "LinkNew")
+	link.SetSourcePad(diagram.GetDiagramNode("NodeNew").GetDefaultConnectionPad())
+	link.SetTargetPad(diagram.GetDiagramNode("Node1").GetDefaultConnectionPad())
+	diagram.Undo()
+
+	ids := []string{}
+	for _, element := range diagram.GetDiagramElements() {
+		ids = append(ids, element.GetDiagramElementID())
+	}
+	assert.Equal(t, []string{"Node2", "Link1", "Link2", "Node1", "NodeNew", "LinkNew"}, ids)
+	assert.Len(t, diagram.diagramElementLinkDependencies["Node1"], 2)
+	assert.Len(t, diagram.diagramElementLinkDependencies["NodeNew"], 1)
+	assert.Len(t, diagram.diagramElementLinkDependencies["Node2"], 1)
+	assert.Len(t, diagram.diagramElementLinkDependencies["Link1"], 1)
+
+	diagram.Redo()
+	assert.Nil(t, diagram.GetDiagramElement("Link1"))
+	assert.NotNil(t, diagram.GetDiagramElement("LinkNew"))
+	assert.Len(t, diagram.diagramElementLinkDependencies["Node1"], 1)
+}
+
+func TestDiagramWidget_UndoLayoutRun(t *testing.T) {
+	test.NewTempApp(t)
+	diagram := makeTestDiagram()
+	node1 := diagram.GetDiagramNode("Node1")
+
+	diagram.StartCommandGroup("Layout")
+	for i := 0; i < 10; i++ {
+		StepForceLayout(diagram, 300)
+	}
+	diagram.EndCommandGroup()
+	assert.NotEqual(t, fyne.NewPos(100, 100), node1.Position())
+	assert.Len(t, diagram.undoStack, 1)
+	assert.Len(t, diagram.undoStack[0].(*commandGroup).commands, 2)
+
+	diagram.Undo()
+	assert.InDelta(t, 100, node1.Position().X, 0.01)
+	assert.InDelta(t, 100, node1.Position().Y, 0.01)
+}
+
+func TestDiagramWidget_HistoryLimit(t *testing.T) {
+	test.NewTempApp(t)
+	diagram := makeTestDiagram()
+	diagram.HistoryLimit = 3
+	node1 := diagram.GetDiagramNode("Node1")
+
+	for i := 0; i < 5; i++ {
+		diagram.DisplaceNode(node1, fyne.NewPos(10, 0))
+	}
+	for diagram.CanUndo() {
+		diagram.Undo()
+	}
+	assert.Equal(t, fyne.NewPos(120, 100), node1.Position())
+}
@@ -80,6 +80,8 @@ type BaseDiagramLink struct {
 	midpointAnchoredText map[string]*AnchoredText
 	// We keep the typed link so that when extensions are created the callbacks are called with the correct type
 	typedLink DiagramLink
+	// dragStartPoint is the position, in diagram coordinates, of the end point being dragged when the drag started
+	dragStartPoint fyne.Position
 }
 
 // NewDiagramLink creates a DiagramLink widget connecting the two indicated ConnectionPads. It adds itself to the
@@ -298,6 +300,7 @@ func (bdl *BaseDiagramLink) handleDragged(handle *Handle, event *fyne.DragEvent)
 	if connTrans == nil {
 		connTrans = NewConnectionTransaction(linkPoint, bdl, pad, linkPoint.Position())
 		bdl.diagram.ConnectionTransaction = connTrans
+		bdl.dragStartPoint = bdl.Position().Add(linkPoint.Position())
 		// TODO remove this after fyne Issue #3906 has been resolved
 		bdl.diagram.showAllPads()
 
@@ -355,6 +358,26 @@ func (bdl *BaseDiagramLink) handleDragEnd(handle *Handle) {
 		bdl.diagram.hideAllPads()
 		bdl.diagram.SelectDiagramElement(bdl)
 		bdl.Refresh()
+		bdl.recordEndChange(handleKey, connTrans)
+	}
+}
+
+// recordEndChange records the command for a drag of one of the link's ends, if it changed the link
+func (bdl *BaseDiagramLink) recordEndChange(handleKey string, connTrans *ConnectionTransaction) {
+	command := &linkEndCommand{
+		link:     bdl,
+		end:      SOURCE,
+		oldPad:   connTrans.InitialPad,
+		newPad:   bdl.sourcePad,
+		oldPoint: bdl.dragStartPoint,
+		newPoint: bdl.Position().Add(connTrans.LinkPoint.Position()),
+	}
+	if handleKey == TARGET.ToString() {
+		command.end = TARGET
+		command.newPad = bdl.targetPad
+	}
+	if command.oldPad != command.newPad || (command.newPad == nil && command.oldPoint != command.newPoint) {
+		bdl.diagram.RecordCommand(command)
 	}
 }
 
@@ -408,6 +431,32 @@ func (bdl *BaseDiagramLink) MouseMoved(event *desktop.MouseEvent) {
 func (bdl *BaseDiagramLink) MouseOut() {
 }
 
+// setEndPad moves one end of the link from one pad to another, either of which may be nil, and
+// invokes the LinkConnectionChangedCallback. An end that is left unconnected is moved to the point,
+// which is in diagram coordinates.
+func (bdl *BaseDiagramLink) setEndPad(end LinkEnd, from ConnectionPad, to ConnectionPad, point fyne.Position) {
+	if from != nil {
+		bdl.diagram.removeLinkDependency(from.GetPadOwner(), bdl, from)
+	}
+	if to != nil {
+		bdl.diagram.addLinkDependency(to.GetPadOwner(), bdl, to)
+	}
+	linkPoint := bdl.linkPoints[0]
+	if end == SOURCE {
+		bdl.sourcePad = to
+	} else {
+		bdl.targetPad = to
+		linkPoint = bdl.linkPoints[len(bdl.linkPoints)-1]
+	}
+	if to == nil {
+		linkPoint.Move(point.Subtract(bdl.Position()))
+	}
+	if from != to && bdl.diagram.LinkConnectionChangedCallback != nil {
+		bdl.diagram.LinkConnectionChangedCallback(bdl.typedLink, end.ToString(), from, to)
+	}
+	bdl.Refresh()
+}
+
 // SetSourcePad sets the source pad (belonging to another DiagramElement) and adds the link dependency to the diagram
 func (bdl *BaseDiagramLink) SetSourcePad(pad ConnectionPad) {
 	oldPad := bdl.sourcePad
@@ -100,8 +100,9 @@ func (bdn *BaseDiagramNode) Cursor() desktop.Cursor {
 	return desktop.DefaultCursor
 }
 
-// DragEnd is presently a no-op
+// DragEnd passes the end of the drag to the diagram, which ends the command recording the move
 func (bdn *BaseDiagramNode) DragEnd() {
+	bdn.diagram.DiagramNodeDragEnd(bdn)
 }
 
 // Dragged passes the DragEvent to the diagram for processing
@@ -140,6 +141,8 @@ func (bdn *BaseDiagramNode) GetEdgePad() ConnectionPad {
 }
 
 func (bdn *BaseDiagramNode) handleDragged(handle *Handle, event *fyne.DragEvent) {
+	bdn.diagram.startDragGroup("Resize")
+	before := getNodeBounds(bdn)
 	// determine which handle it is
 	currentInnerSize := bdn.effectiveInnerSize()
 	handleKey := bdn.findKeyForHandle(handle)
@@ -190,10 +193,11 @@ func (bdn *BaseDiagramNode) handleDragged(handle *Handle, event *fyne.DragEvent)
 	bdn.Resize(bdn.Size().Add(sizeChange))
 	bdn.Move(bdn.Position().Add(positionChange))
 	bdn.Refresh()
+	bdn.diagram.RecordCommand(&resizeCommand{node: bdn, before: before, after: getNodeBounds(bdn)})
 }
 
 func (bdn *BaseDiagramNode) handleDragEnd(handle *Handle) {
-
+	bdn.diagram.endDragGroup()
 }
 
 func (bdn *BaseDiagramNode) innerPos() fyne.Position {
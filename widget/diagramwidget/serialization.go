@@ -0,0 +1,555 @@
+package diagramwidget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"sort"
+
+	"fyne.io/fyne/v2"
+)
+
+// Decoration types used in the saved form of a diagram
+const (
+	ArrowheadDecorationType = "arrowhead"
+	PolygonDecorationType   = "polygon"
+)
+
+// DiagramData is the saved form of a DiagramWidget, as written by DiagramWidget.Marshal in JSON.
+// Colors are written as "#rrggbbaa" strings, and positions are in diagram coordinates.
+type DiagramData struct {
+	ID string
+	// Properties are the DefaultDiagramElementProperties of the diagram
+	Properties DiagramElementPropertiesData
+	// Elements are the nodes and links of the diagram, from back to front
+	Elements []DiagramElementData
+}
+
+// DiagramElementData is the saved form of a single DiagramElement. Exactly one of Node and Link is set.
+type DiagramElementData struct {
+	Node *DiagramNodeData `json:",omitempty"`
+	Link *DiagramLinkData `json:",omitempty"`
+}
+
+// DiagramElementPropertiesData is the saved form of DiagramElementProperties
+type DiagramElementPropertiesData struct {
+	ForegroundColor   string `json:",omitempty"`
+	BackgroundColor   string `json:",omitempty"`
+	HandleColor       string `json:",omitempty"`
+	PadColor          string `json:",omitempty"`
+	TextSize          float32
+	CaptionTextSize   float32
+	Padding           float32
+	StrokeWidth       float32
+	PadStrokeWidth    float32
+	HandleStrokeWidth float32
+}
+
+// DiagramNodeData is the saved form of a DiagramNode
+type DiagramNodeData struct {
+	ID string
+	// Type is the name the node's factory was registered with, or empty for a BaseDiagramNode
+	Type       string `json:",omitempty"`
+	Position   fyne.Position
+	InnerSize  fyne.Size
+	Properties DiagramElementPropertiesData
+	// Data is the application data returned by the node's MarshalDiagramElement method
+	Data json.RawMessage `json:",omitempty"`
+}
+
+// DiagramLinkData is the saved form of a DiagramLink
+type DiagramLinkData struct {
+	ID string
+	// Type is the name the link's factory was registered with, or empty for a BaseDiagramLink
+	Type string `json:",omitempty"`
+	// SourcePad and TargetPad are the pads the ends of the link are connected to, if any
+	SourcePad *DiagramPadData `json:",omitempty"`
+	TargetPad *DiagramPadData `json:",omitempty"`
+	// Points are the positions of the link points, which are used for ends that are not connected to a pad
+	Points              []fyne.Position
+	SourceDecorations   []DiagramDecorationData `json:",omitempty"`
+	MidpointDecorations []DiagramDecorationData `json:",omitempty"`
+	TargetDecorations   []DiagramDecorationData `json:",omitempty"`
+	SourceTexts         []AnchoredTextData      `json:",omitempty"`
+	MidpointTexts       []AnchoredTextData      `json:",omitempty"`
+	TargetTexts         []AnchoredTextData      `json:",omitempty"`
+	Properties          DiagramElementPropertiesData
+	// Data is the application data returned by the link's MarshalDiagramElement method
+	Data json.RawMessage `json:",omitempty"`
+}
+
+// DiagramPadData identifies a ConnectionPad by the ID of the element that owns it
+// and the key of the pad in the element's GetConnectionPads map
+type DiagramPadData struct {
+	Element string
+	Pad     string
+}
+
+// DiagramDecorationData is the saved form of an Arrowhead or Polygon decoration.
+// The colors and stroke width of a decoration are taken from its link, and its angle follows the link.
+type DiagramDecorationData struct {
+	// Type is either ArrowheadDecorationType or PolygonDecorationType
+	Type string
+	// Theta and Length are the shape of an arrowhead
+	Theta  float64 `json:",omitempty"`
+	Length int     `json:",omitempty"`
+	// Points, Closed and Solid are the shape of a polygon
+	Points []fyne.Position `json:",omitempty"`
+	Closed bool            `json:",omitempty"`
+	Solid  bool            `json:",omitempty"`
+}
+
+// AnchoredTextData is the saved form of an AnchoredText
+type AnchoredTextData struct {
+	// Key is the key the text was added to the link with
+	Key  string
+	Text string
+	// Offset is the position of the text relative to its reference point on the link
+	Offset          fyne.Position
+	ForegroundColor string `json:",omitempty"`
+}
+
+// DiagramElementMarshaler is implemented by application node and link types that are saved by
+// DiagramWidget.Marshal. Nodes and links that do not implement it are saved as a BaseDiagramNode
+// or BaseDiagramLink.
+type DiagramElementMarshaler interface {
+	// DiagramElementType returns the name that the factory for the type was registered with
+	DiagramElementType() string
+	// MarshalDiagramElement returns the application data to save with the element. It is passed to the
+	// factory when the diagram is loaded, and may be nil.
+	MarshalDiagramElement() (json.RawMessage, error)
+}
+
+// DiagramNodeFactory creates a node of an application type while a diagram is loaded. It is called with
+// the data returned by MarshalDiagramElement and must create the node in the diagram with the ID supplied,
+// normally using InitializeBaseDiagramNode. The position, size and properties are set after it returns.
+type DiagramNodeFactory func(diagram *DiagramWidget, nodeID string, data json.RawMessage) (DiagramNode, error)
+
+// DiagramLinkFactory creates a link of an application type while a diagram is loaded. It is called with
+// the data returned by MarshalDiagramElement and must create the link in the diagram with the ID supplied,
+// normally using InitializeBaseDiagramLink. The connections, decorations, anchored texts and properties
+// are set after it returns.
+type DiagramLinkFactory func(diagram *DiagramWidget, linkID string, data json.RawMessage) (DiagramLink, error)
+
+// RegisterNodeType sets the factory used by Unmarshal to create nodes that were saved with the type name
+func (dw *DiagramWidget) RegisterNodeType(typeName string, factory DiagramNodeFactory) {
+	if dw.nodeFactories == nil {
+		dw.nodeFactories = map[string]DiagramNodeFactory{}
+	}
+	dw.nodeFactories[typeName] = factory
+}
+
+// RegisterLinkType sets the factory used by Unmarshal to create links that were saved with the type name
+func (dw *DiagramWidget) RegisterLinkType(typeName string, factory DiagramLinkFactory) {
+	if dw.linkFactories == nil {
+		dw.linkFactories = map[string]DiagramLinkFactory{}
+	}
+	dw.linkFactories[typeName] = factory
+}
+
+// Marshal returns the contents of the diagram as JSON in the format described by DiagramData.
+// The canvas objects inside nodes are not saved: application node types that have content should implement
+// DiagramElementMarshaler and recreate the content in their factory.
+func (dw *DiagramWidget) Marshal() ([]byte, error) {
+	data := DiagramData{ID: dw.ID, Properties: makePropertiesData(dw.DefaultDiagramElementProperties)}
+	for _, element := range dw.GetDiagramElements() {
+		typeName, appData, err := marshalElementType(element)
+		if err != nil {
+			return nil, err
+		}
+		switch e := element.(type) {
+		case DiagramNode:
+			data.Elements = append(data.Elements, DiagramElementData{Node: makeNodeData(e, typeName, appData)})
+		case DiagramLink:
+			data.Elements = append(data.Elements, DiagramElementData{Link: makeLinkData(e, typeName, appData)})
+		}
+	}
+	return json.MarshalIndent(data, "", "  ")
+}
+
+// Unmarshal replaces the contents of the diagram with nodes and links read from JSON written by Marshal.
+// Nodes and links with a type name are created by the factories registered with RegisterNodeType and
+// RegisterLinkType. The connection callbacks are not called while the diagram is loaded, and the
+// command history is cleared. If the data is invalid, or a factory returns an error, the diagram and
+// its history are left as they were.
+func (dw *DiagramWidget) Unmarshal(content []byte) error {
+	var data DiagramData
+	if err := json.Unmarshal(content, &data); err != nil {
+		return err
+	}
+	if err := dw.validateDiagramData(&data); err != nil {
+		return err
+	}
+
+	// elements add themselves to the display list as they are created, so the new elements are loaded
+	// into an empty list and the previous elements are put back if loading fails
+	previousElements := dw.GetDiagramElements()
+	previousDependencies := dw.diagramElementLinkDependencies
+	previousID := dw.ID
+	previousProperties := dw.DefaultDiagramElementProperties
+	dw.DiagramElements.Init()
+	dw.diagramElementLinkDependencies = map[string][]linkPadPair{}
+	dw.ID = data.ID
+	dw.DefaultDiagramElementProperties = data.Properties.toProperties()
+
+	var err error
+	dw.replay(func() {
+		err = dw.loadElements(&data)
+	})
+	if err != nil {
+		dw.DiagramElements.Init()
+		for _, element := range previousElements {
+			dw.DiagramElements.PushBack(element)
+		}
+		dw.diagramElementLinkDependencies = previousDependencies
+		dw.ID = previousID
+		dw.DefaultDiagramElementProperties = previousProperties
+		dw.adjustBounds()
+		dw.drawingArea.Refresh()
+		return err
+	}
+
+	dw.ClearSelectionNoCallback()
+	dw.ConnectionTransaction = nil
+	dw.ClearHistory()
+	for _, link := range dw.GetDiagramLinks() {
+		link.Refresh()
+	}
+	dw.adjustBounds()
+	dw.drawingArea.Refresh()
+	return nil
+}
+
+// validateDiagramData checks that the element IDs are unique, that the types have been registered,
+// and that all of the link connections are to elements that will exist. The pads are checked when
+// the links are connected, as the pads of an element are only known once it has been created.
+func (dw *DiagramWidget) validateDiagramData(data *DiagramData) error {
+	ids := map[string]bool{}
+	for _, element := range data.Elements {
+		var id string
+		switch {
+		case element.Node != nil && element.Link != nil:
+			return fmt.Errorf("diagram element %q is both a node and a link", element.Node.ID)
+		case element.Node != nil:
+			id = element.Node.ID
+			if _, ok := dw.nodeFactories[element.Node.Type]; element.Node.Type != "" && !ok {
+				return fmt.Errorf("no factory registered for node type %q", element.Node.Type)
+			}
+		case element.Link != nil:
+			id = element.Link.ID
+			if _, ok := dw.linkFactories[element.Link.Type]; element.Link.Type != "" && !ok {
+				return fmt.Errorf("no factory registered for link type %q", element.Link.Type)
+			}
+		default:
+			return errors.New("diagram element is neither a node nor a link")
+		}
+		if ids[id] {
+			return fmt.Errorf("duplicate diagram element ID %q", id)
+		}
+		ids[id] = true
+	}
+
+	for _, element := range data.Elements {
+		if element.Link == nil {
+			continue
+		}
+		for _, pad := range []*DiagramPadData{element.Link.SourcePad, element.Link.TargetPad} {
+			if pad != nil && !ids[pad.Element] {
+				return fmt.Errorf("link %q is connected to missing element %q", element.Link.ID, pad.Element)
+			}
+		}
+		for _, decorations := range [][]DiagramDecorationData{element.Link.SourceDecorations,
+			element.Link.MidpointDecorations, element.Link.TargetDecorations} {
+			for _, decoration := range decorations {
+				if decoration.Type != ArrowheadDecorationType && decoration.Type != PolygonDecorationType {
+					return fmt.Errorf("link %q has unknown decoration type %q", element.Link.ID, decoration.Type)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// loadElements creates the elements of the diagram data in the display list and connects the links
+func (dw *DiagramWidget) loadElements(data *DiagramData) error {
+	// the links are connected after all of the elements exist, as links can connect to links later in the list
+	var links []*DiagramLinkData
+	for _, element := range data.Elements {
+		var err error
+		if element.Node != nil {
+			err = dw.loadNode(element.Node)
+		} else {
+			err = dw.loadLink(element.Link)
+			links = append(links, element.Link)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for _, linkData := range links {
+		if err := dw.connectLink(linkData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (dw *DiagramWidget) loadNode(data *DiagramNodeData) error {
+	var node DiagramNode
+	if data.Type == "" {
+		node = NewDiagramNode(dw, nil, data.ID)
+	} else {
+		var err error
+		node, err = dw.nodeFactories[data.Type](dw, data.ID, data.Data)
+		if err != nil {
+			return fmt.Errorf("creating node %q: %w", data.ID, err)
+		}
+		if node == nil || dw.GetDiagramNode(data.ID) != node {
+			return fmt.Errorf("factory for node type %q did not create node %q in the diagram", data.Type, data.ID)
+		}
+	}
+
+	node.SetProperties(data.Properties.toProperties())
+	node.getBaseDiagramNode().InnerSize = data.InnerSize
+	node.Move(data.Position)
+	node.Refresh()
+	return nil
+}
+
+func (dw *DiagramWidget) loadLink(data *DiagramLinkData) error {
+	var link DiagramLink
+	if data.Type == "" {
+		link = NewDiagramLink(dw, data.ID)
+	} else {
+		var err error
+		link, err = dw.linkFactories[data.Type](dw, data.ID, data.Data)
+		if err != nil {
+			return fmt.Errorf("creating link %q: %w", data.ID, err)
+		}
+		if link == nil || dw.GetDiagramLink(data.ID) != link {
+			return fmt.Errorf("factory for link type %q did not create link %q in the diagram", data.Type, data.ID)
+		}
+	}
+
+	link.SetProperties(data.Properties.toProperties())
+	bdl := link.getBaseDiagramLink()
+	for _, decoration := range data.SourceDecorations {
+		bdl.AddSourceDecoration(decoration.toDecoration())
+	}
+	for _, decoration := range data.MidpointDecorations {
+		bdl.AddMidpointDecoration(decoration.toDecoration())
+	}
+	for _, decoration := range data.TargetDecorations {
+		bdl.AddTargetDecoration(decoration.toDecoration())
+	}
+	for _, text := range data.SourceTexts {
+		text.apply(bdl.AddSourceAnchoredText(text.Key, text.Text))
+	}
+	for _, text := range data.MidpointTexts {
+		text.apply(bdl.AddMidpointAnchoredText(text.Key, text.Text))
+	}
+	for _, text := range data.TargetTexts {
+		text.apply(bdl.AddTargetAnchoredText(text.Key, text.Text))
+	}
+
+	// the link is at the origin until it is refreshed, so points in diagram coordinates are also link coordinates
+	if len(data.Points) >= 2 {
+		bdl.linkPoints[0].Move(data.Points[0])
+		bdl.linkPoints[len(bdl.linkPoints)-1].Move(data.Points[len(data.Points)-1])
+	}
+	return nil
+}
+
+// connectLink sets the pads of a loaded link directly, so that LinkConnectionChangedCallback is not called
+func (dw *DiagramWidget) connectLink(data *DiagramLinkData) error {
+	bdl := dw.GetDiagramLink(data.ID).getBaseDiagramLink()
+	sourcePad, err := dw.findPad(data.ID, data.SourcePad)
+	if err != nil {
+		return err
+	}
+	targetPad, err := dw.findPad(data.ID, data.TargetPad)
+	if err != nil {
+		return err
+	}
+	if sourcePad != nil {
+		bdl.sourcePad = sourcePad
+		dw.addLinkDependency(sourcePad.GetPadOwner(), bdl, sourcePad)
+	}
+	if targetPad != nil {
+		bdl.targetPad = targetPad
+		dw.addLinkDependency(targetPad.GetPadOwner(), bdl, targetPad)
+	}
+	return nil
+}
+
+// findPad returns the pad a link is connected to, or nil if the link end is not connected
+func (dw *DiagramWidget) findPad(linkID string, data *DiagramPadData) (ConnectionPad, error) {
+	if data == nil {
+		return nil, nil
+	}
+	pad := dw.GetDiagramElement(data.Element).GetConnectionPads()[data.Pad]
+	if pad == nil {
+		return nil, fmt.Errorf("link %q is connected to missing pad %q of element %q", linkID, data.Pad, data.Element)
+	}
+	return pad, nil
+}
+
+func marshalElementType(element DiagramElement) (string, json.RawMessage, error) {
+	m, ok := element.(DiagramElementMarshaler)
+	if !ok {
+		return "", nil, nil
+	}
+	data, err := m.MarshalDiagramElement()
+	if err != nil {
+		return "", nil, fmt.Errorf("saving diagram element %q: %w", element.GetDiagramElementID(), err)
+	}
+	return m.DiagramElementType(), data, nil
+}
+
+func makeNodeData(node DiagramNode, typeName string, appData json.RawMessage) *DiagramNodeData {
+	return &DiagramNodeData{
+		ID:         node.GetDiagramElementID(),
+		Type:       typeName,
+		Position:   node.Position(),
+		InnerSize:  node.getBaseDiagramNode().InnerSize,
+		Properties: makePropertiesData(node.GetProperties()),
+		Data:       appData,
+	}
+}
+
+func makeLinkData(link DiagramLink, typeName string, appData json.RawMessage) *DiagramLinkData {
+	bdl := link.getBaseDiagramLink()
+	data := &DiagramLinkData{
+		ID:                  link.GetDiagramElementID(),
+		Type:                typeName,
+		SourcePad:           makePadData(bdl.sourcePad),
+		TargetPad:           makePadData(bdl.targetPad),
+		SourceDecorations:   makeDecorationsData(bdl.SourceDecorations),
+		MidpointDecorations: makeDecorationsData(bdl.MidpointDecorations),
+		TargetDecorations:   makeDecorationsData(bdl.TargetDecorations),
+		SourceTexts:         makeAnchoredTextsData(bdl.sourceAnchoredText),
+		MidpointTexts:       makeAnchoredTextsData(bdl.midpointAnchoredText),
+		TargetTexts:         makeAnchoredTextsData(bdl.targetAnchoredText),
+		Properties:          makePropertiesData(link.GetProperties()),
+		Data:                appData,
+	}
+	for _, point := range bdl.linkPoints {
+		data.Points = append(data.Points, bdl.Position().Add(point.Position()))
+	}
+	return data
+}
+
+func makePadData(pad ConnectionPad) *DiagramPadData {
+	if pad == nil {
+		return nil
+	}
+	owner := pad.GetPadOwner()
+	for key, p := range owner.GetConnectionPads() {
+		if p == pad {
+			return &DiagramPadData{Element: owner.GetDiagramElementID(), Pad: key}
+		}
+	}
+	return nil
+}
+
+func makeDecorationsData(decorations []Decoration) []DiagramDecorationData {
+	var data []DiagramDecorationData
+	for _, decoration := range decorations {
+		switch d := decoration.(type) {
+		case *Arrowhead:
+			data = append(data, DiagramDecorationData{Type: ArrowheadDecorationType, Theta: d.Theta, Length: d.Length})
+		case *Polygon:
+			data = append(data, DiagramDecorationData{Type: PolygonDecorationType,
+				Points: d.definingPoints, Closed: d.closed, Solid: d.solid})
+		}
+	}
+	return data
+}
+
+func (d DiagramDecorationData) toDecoration() Decoration {
+	if d.Type == ArrowheadDecorationType {
+		arrowhead := NewArrowhead()
+		arrowhead.Theta = d.Theta
+		arrowhead.Length = d.Length
+		return arrowhead
+	}
+	polygon := NewPolygon(d.Points)
+	polygon.closed = d.Closed
+	polygon.solid = d.Solid
+	return polygon
+}
+
+// makeAnchoredTextsData returns the texts sorted by key so that the saved form does not change between saves
+func makeAnchoredTextsData(texts map[string]*AnchoredText) []AnchoredTextData {
+	var data []AnchoredTextData
+	for key, text := range texts {
+		value, _ := text.displayedTextBinding.Get()
+		data = append(data, AnchoredTextData{
+			Key:             key,
+			Text:            value,
+			Offset:          fyne.NewPos(float32(text.offset.X), float32(text.offset.Y)),
+			ForegroundColor: colorToString(text.ForegroundColor),
+		})
+	}
+	sort.Slice(data, func(i, j int) bool {
+		return data[i].Key < data[j].Key
+	})
+	return data
+}
+
+// apply moves a newly added anchored text to its saved offset and sets its color
+func (d AnchoredTextData) apply(text *AnchoredText) {
+	text.Displace(fyne.NewPos(d.Offset.X-float32(text.offset.X), d.Offset.Y-float32(text.offset.Y)))
+	if c := stringToColor(d.ForegroundColor); c != nil {
+		text.SetForegroundColor(c)
+	}
+}
+
+func makePropertiesData(p DiagramElementProperties) DiagramElementPropertiesData {
+	return DiagramElementPropertiesData{
+		ForegroundColor:   colorToString(p.ForegroundColor),
+		BackgroundColor:   colorToString(p.BackgroundColor),
+		HandleColor:       colorToString(p.HandleColor),
+		PadColor:          colorToString(p.PadColor),
+		TextSize:          p.TextSize,
+		CaptionTextSize:   p.CaptionTextSize,
+		Padding:           p.Padding,
+		StrokeWidth:       p.StrokeWidth,
+		PadStrokeWidth:    p.PadStrokeWidth,
+		HandleStrokeWidth: p.HandleStrokeWidth,
+	}
+}
+
+func (d DiagramElementPropertiesData) toProperties() DiagramElementProperties {
+	return DiagramElementProperties{
+		ForegroundColor:   stringToColor(d.ForegroundColor),
+		BackgroundColor:   stringToColor(d.BackgroundColor),
+		HandleColor:       stringToColor(d.HandleColor),
+		PadColor:          stringToColor(d.PadColor),
+		TextSize:          d.TextSize,
+		CaptionTextSize:   d.CaptionTextSize,
+		Padding:           d.Padding,
+		StrokeWidth:       d.StrokeWidth,
+		PadStrokeWidth:    d.PadStrokeWidth,
+		HandleStrokeWidth: d.HandleStrokeWidth,
+	}
+}
+
+// colorToString returns a color as "#rrggbbaa", or an empty string if it is nil
+func colorToString(c color.Color) string {
+	if c == nil {
+		return ""
+	}
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("#%02x%02x%02x%02x", n.R, n.G, n.B, n.A)
+}
+
+// stringToColor returns the color written by colorToString, or nil if the string is not a color
+func stringToColor(s string) color.Color {
+	var c color.NRGBA
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x%02x", &c.R, &c.G, &c.B, &c.A); err != nil {
+		return nil
+	}
+	return c
+}
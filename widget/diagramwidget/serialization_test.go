@@ -0,0 +1,175 @@
+package diagramwidget
+
+import (
+	"encoding/json"
+	"errors"
+	"image/color"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+	"github.com/stretchr/testify/assert"
+)
+
+// labelNode is an application node type that shows a label
+type labelNode struct {
+	BaseDiagramNode
+	label *widget.Label
+}
+
+func newLabelNode(diagram *DiagramWidget, id, text string) *labelNode {
+	node := &labelNode{label: widget.NewLabel(text)}
+	InitializeBaseDiagramNode(node, diagram, node.label, id)
+	return node
+}
+
+func (n *labelNode) DiagramElementType() string {
+	return "label"
+}
+
+func (n *labelNode) MarshalDiagramElement() (json.RawMessage, error) {
+	return json.Marshal(n.label.Text)
+}
+
+func registerLabelNode(diagram *DiagramWidget) {
+	diagram.RegisterNodeType("label", func(d *DiagramWidget, id string, data json.RawMessage) (DiagramNode, error) {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return nil, err
+		}
+		return newLabelNode(d, id, text), nil
+	})
+}
+
+func makeTestDiagram() *DiagramWidget {
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := newLabelNode(diagram, "Node1", "Hello")
+	node1.Move(fyne.NewPos(100, 100))
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(300, 150))
+	node2.getBaseDiagramNode().InnerSize = fyne.NewSize(80, 40)
+	node2.SetProperties(DiagramElementProperties{ForegroundColor: color.NRGBA{R: 0xff, A: 0xff}, StrokeWidth: 2, Padding: 4})
+	node2.Refresh()
+
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(node1.GetDefaultConnectionPad())
+	link.SetTargetPad(node2.GetDefaultConnectionPad())
+	link.AddTargetDecoration(NewArrowhead())
+	polygon := NewPolygon([]fyne.Position{{X: 0, Y: 0}, {X: 8, Y: 4}, {X: 16, Y: 0}, {X: 8, Y: -4}})
+	polygon.SetSolid(true)
+	link.AddSourceDecoration(polygon)
+	link.AddMidpointAnchoredText("name", "uses").Displace(fyne.NewPos(5, -20))
+
+	loose := NewDiagramLink(diagram, "Link2")
+	loose.SetSourcePad(link.GetMidPad())
+	loose.GetLinkPoints()[1].Move(fyne.NewPos(400, 400))
+	loose.Refresh()
+	return diagram
+}
+
+func TestDiagramWidget_MarshalRoundTrip(t *testing.T) {
+	test.NewTempApp(t)
+	diagram := makeTestDiagram()
+	saved, err := diagram.Marshal()
+	assert.NoError(t, err)
+
+	loaded := NewDiagramWidget("")
+	registerLabelNode(loaded)
+	assert.NoError(t, loaded.Unmarshal(saved))
+	assert.Equal(t, "Diagram1", loaded.ID)
+	assert.Len(t, loaded.GetDiagramNodes(), 2)
+	assert.Len(t, loaded.GetDiagramLinks(), 2)
+
+	node1, ok := loaded.GetDiagramNode("Node1").(*labelNode)
+	assert.True(t, ok)
+	assert.Equal(t, "Hello", node1.label.Text)
+	assert.Equal(t, fyne.NewPos(100, 100), node1.Position())
+
+	node2 := loaded.GetDiagramNode("Node2")
+	assert.Equal(t, fyne.NewPos(300, 150), node2.Position())
+	assert.Equal(t, fyne.NewSize(80, 40), node2.getBaseDiagramNode().InnerSize)
+	assert.Equal(t, color.NRGBA{R: 0xff, A: 0xff}, node2.GetForegroundColor())
+	assert.Equal(t, float32(2), node2.GetProperties().StrokeWidth)
+
+	link := loaded.GetDiagramLink("Link1").(*BaseDiagramLink)
+	assert.Equal(t, node1.GetDefaultConnectionPad(), link.GetSourcePad())
+	assert.Equal(t, node2.GetDefaultConnectionPad(), link.GetTargetPad())
+	assert.Len(t, loaded.diagramElementLinkDependencies["Node1"], 1)
+	assert.IsType(t, &Arrowhead{}, link.TargetDecorations[0])
+	assert.True(t, link.SourceDecorations[0].(*Polygon).solid)
+	text := link.GetMidpointAnchoredText("name")
+	value, _ := text.GetDisplayedTextBinding().Get()
+	assert.Equal(t, "uses", value)
+	assert.Equal(t, link.getMidPosition().AddXY(5, -20), text.Position())
+
+	loose := loaded.GetDiagramLink("Link2")
+	assert.Equal(t, link.GetMidPad(), loose.GetSourcePad())
+	assert.Nil(t, loose.GetTargetPad())
+	end := loose.GetLinkPoints()[1]
+	assert.Equal(t, fyne.NewPos(400, 400), loose.Position().Add(end.Position()))
+
+	again, err := loaded.Marshal()
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(saved), string(again))
+}
+
+func TestDiagramWidget_UnmarshalErrors(t *testing.T) {
+	test.NewTempApp(t)
+	saved, err := makeTestDiagram().Marshal()
+	assert.NoError(t, err)
+
+	diagram := NewDiagramWidget("Existing")
+	NewDiagramNode(diagram, nil, "Node")
+	err = diagram.Unmarshal(saved)
+	assert.ErrorContains(t, err, `no factory registered for node type "label"`)
+	assert.Equal(t, "Existing", diagram.ID)
+	assert.NotNil(t, diagram.GetDiagramNode("Node"))
+
+	err = diagram.Unmarshal([]byte(`{"Elements": [{"Node": {"ID": "A"}}, {"Node": {"ID": "A"}}]}`))
+	assert.ErrorContains(t, err, "duplicate")
+	err = diagram.Unmarshal([]byte(`{"Elements": [{"Link": {"ID": "L", "SourcePad": {"Element": "X", "Pad": "default"}}}]}`))
+	assert.ErrorContains(t, err, "missing element")
+	err = diagram.Unmarshal([]byte(`{"Elements": [{"Node": {"ID": "N"}}, {"Link": {"ID": "L", "SourcePad": {"Element": "N", "Pad": "nope"}}}]}`))
+	assert.ErrorContains(t, err, `missing pad "nope" of element "N"`)
+	assert.Len(t, diagram.GetDiagramElements(), 1)
+}
+
+func TestDiagramWidget_UnmarshalFactoryErrors(t *testing.T) {
+	test.NewTempApp(t)
+	source := makeTestDiagram()
+	saved, err := source.Marshal()
+	assert.NoError(t, err)
+
+	diagram := makeTestDiagram()
+	diagram.DisplaceNode(diagram.GetDiagramNode("Node2"), fyne.NewPos(10, 0))
+	elements := diagram.GetDiagramElements()
+
+	// the factory of a type that fails, or that does not create the element it was asked for
+	factories := []DiagramNodeFactory{
+		func(*DiagramWidget, string, json.RawMessage) (DiagramNode, error) {
+			return nil, errors.New("bad label")
+		},
+		func(*DiagramWidget, string, json.RawMessage) (DiagramNode, error) {
+			return nil, nil
+		},
+		func(d *DiagramWidget, id string, data json.RawMessage) (DiagramNode, error) {
+			return newLabelNode(d, id+"-copy", ""), nil
+		},
+		func(d *DiagramWidget, id string, data json.RawMessage) (DiagramNode, error) {
+			return newLabelNode(source, id, ""), nil
+		},
+	}
+	for _, factory := range factories {
+		diagram.RegisterNodeType("label", factory)
+		assert.Error(t, diagram.Unmarshal(saved))
+		assert.Equal(t, elements, diagram.GetDiagramElements())
+		assert.Len(t, diagram.diagramElementLinkDependencies["Node2"], 1)
+		assert.Equal(t, "Move", diagram.UndoName())
+	}
+
+	registerLabelNode(diagram)
+	assert.NoError(t, diagram.Unmarshal(saved))
+	assert.NotEqual(t, elements, diagram.GetDiagramElements())
+	assert.False(t, diagram.CanUndo())
+}
@@ -58,7 +58,9 @@ func calculateForce(dw *DiagramWidget, n1, n2 DiagramNode, targetLength float64)
 }
 
 // StepForceLayout calculates one step of force directed graph layout, with
-// the target distance between adjacent nodes being targetLength.
+// the target distance between adjacent nodes being targetLength. The moves are
+// recorded in the diagram's history, so callers that run several steps should
+// wrap the whole run in StartCommandGroup and EndCommandGroup to undo it as one step.
 func StepForceLayout(dw *DiagramWidget, targetLength float64) {
 	deltas := make(map[int]r2.Vec2)
 
@@ -0,0 +1,156 @@
+package diagramwidget
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+)
+
+// WriteSVG writes the diagram as a standalone SVG document the size of the drawing area. It draws
+// the node boxes, the link segments, the link decorations rotated to match the links, and the
+// AnchoredText labels, using the colors and stroke widths of each element's DiagramElementProperties.
+// The canvas objects inside nodes are application content, so they are not drawn. Elements are written
+// from back to front in display order. WriteSVG only uses the state of the elements, so it can be used
+// without showing the diagram in a window.
+func (dw *DiagramWidget) WriteSVG(w io.Writer) error {
+	var buf bytes.Buffer
+	width, height := svgNumber(dw.DesiredSize.Width), svgNumber(dw.DesiredSize.Height)
+	buf.WriteString(xml.Header)
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s">`+"\n",
+		width, height, width, height)
+	fmt.Fprintf(&buf, `<rect width="%s" height="%s"%s/>`+"\n", width, height, svgPaint("fill", dw.GetBackgroundColor()))
+	for _, element := range dw.GetDiagramElements() {
+		if !element.Visible() {
+			continue
+		}
+		if element.IsNode() {
+			writeSVGNode(&buf, element.(DiagramNode).getBaseDiagramNode())
+		} else {
+			dw.writeSVGLink(&buf, element.(DiagramLink).getBaseDiagramLink())
+		}
+	}
+	buf.WriteString("</svg>\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeSVGNode(buf *bytes.Buffer, node *BaseDiagramNode) {
+	box := node.R2Box()
+	fmt.Fprintf(buf, `<rect id="%s" x="%s" y="%s" width="%s" height="%s"%s%s/>`+"\n",
+		svgEscape(node.id), svgNumber(float32(box.A.X)), svgNumber(float32(box.A.Y)),
+		svgNumber(float32(box.Width())), svgNumber(float32(box.Height())),
+		svgPaint("fill", node.properties.BackgroundColor), svgStroke(node.properties))
+}
+
+func (dw *DiagramWidget) writeSVGLink(buf *bytes.Buffer, link *BaseDiagramLink) {
+	origin := link.Position()
+	fmt.Fprintf(buf, `<g id="%s">`+"\n", svgEscape(link.id))
+	for _, segment := range link.linkSegments {
+		p1 := origin.Add(segment.p1)
+		p2 := origin.Add(segment.p2)
+		fmt.Fprintf(buf, `<line x1="%s" y1="%s" x2="%s" y2="%s"%s/>`+"\n",
+			svgNumber(p1.X), svgNumber(p1.Y), svgNumber(p2.X), svgNumber(p2.Y), svgStroke(link.properties))
+	}
+	for _, decorations := range [][]Decoration{link.SourceDecorations, link.MidpointDecorations, link.TargetDecorations} {
+		for _, decoration := range decorations {
+			dw.writeSVGDecoration(buf, link, decoration)
+		}
+	}
+	for _, texts := range []map[string]*AnchoredText{link.sourceAnchoredText, link.midpointAnchoredText, link.targetAnchoredText} {
+		keys := make([]string, 0, len(texts))
+		for key := range texts {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			writeSVGAnchoredText(buf, link, texts[key])
+		}
+	}
+	buf.WriteString("</g>\n")
+}
+
+// writeSVGDecoration draws a Polygon or Arrowhead rotated to the angle of the link. Other types of decoration are not drawn.
+func (dw *DiagramWidget) writeSVGDecoration(buf *bytes.Buffer, link *BaseDiagramLink, decoration Decoration) {
+	reference := link.Position().Add(decoration.Position())
+	switch d := decoration.(type) {
+	case *Polygon:
+		element := "polyline"
+		fill := svgPaint("fill", nil)
+		if d.closed {
+			element = "polygon"
+			if d.solid {
+				fill = svgPaint("fill", link.properties.ForegroundColor)
+			} else {
+				fill = svgPaint("fill", dw.GetBackgroundColor())
+			}
+		}
+		fmt.Fprintf(buf, `<%s points="%s"%s%s/>`+"\n", element, svgPoints(reference, d.getRotatedPoints()), fill, svgStroke(link.properties))
+	case *Arrowhead:
+		points := []fyne.Position{d.LeftPoint(), {}, d.RightPoint()}
+		fmt.Fprintf(buf, `<polyline points="%s"%s%s/>`+"\n", svgPoints(reference, points), svgPaint("fill", nil), svgStroke(link.properties))
+	}
+}
+
+func writeSVGAnchoredText(buf *bytes.Buffer, link *BaseDiagramLink, at *AnchoredText) {
+	text, _ := at.displayedTextBinding.Get()
+	if text == "" {
+		return
+	}
+	textColor := at.ForegroundColor
+	if textColor == nil {
+		textColor = link.properties.ForegroundColor
+	}
+	// the text entry is placed 5 from the position of the AnchoredText, and has its own padding
+	inset := 5 + theme.InnerPadding()
+	position := link.Position().Add(at.Position()).AddXY(inset, inset)
+	fmt.Fprintf(buf, `<text x="%s" y="%s" font-family="sans-serif" font-size="%s" dominant-baseline="hanging"%s>%s</text>`+"\n",
+		svgNumber(position.X), svgNumber(position.Y), svgNumber(link.properties.TextSize), svgPaint("fill", textColor), svgEscape(text))
+}
+
+func svgEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func svgNumber(v float32) string {
+	return strconv.FormatFloat(float64(v), 'f', -1, 32)
+}
+
+// svgPaint returns the attributes for a fill or stroke color, including its opacity if it is not opaque
+func svgPaint(attribute string, c color.Color) string {
+	if c == nil {
+		return fmt.Sprintf(` %s="none"`, attribute)
+	}
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if nrgba.A == 0 {
+		return fmt.Sprintf(` %s="none"`, attribute)
+	}
+	paint := fmt.Sprintf(` %s="#%02x%02x%02x"`, attribute, nrgba.R, nrgba.G, nrgba.B)
+	if nrgba.A != 0xff {
+		paint += fmt.Sprintf(` %s-opacity="%s"`, attribute, strconv.FormatFloat(float64(nrgba.A)/0xff, 'f', 3, 64))
+	}
+	return paint
+}
+
+// svgPoints returns the points attribute value for points relative to a reference position
+func svgPoints(reference fyne.Position, points []fyne.Position) string {
+	values := make([]string, len(points))
+	for i, point := range points {
+		p := reference.Add(point)
+		values[i] = svgNumber(p.X) + "," + svgNumber(p.Y)
+	}
+	return strings.Join(values, " ")
+}
+
+func svgStroke(properties DiagramElementProperties) string {
+	return svgPaint("stroke", properties.ForegroundColor) + fmt.Sprintf(` stroke-width="%s"`, svgNumber(properties.StrokeWidth))
+}
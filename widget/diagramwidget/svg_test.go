@@ -0,0 +1,93 @@
+package diagramwidget
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/color"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type svgTestShape struct {
+	ID     string `xml:"id,attr"`
+	X      string `xml:"x,attr"`
+	Y      string `xml:"y,attr"`
+	X1     string `xml:"x1,attr"`
+	Y1     string `xml:"y1,attr"`
+	Width  string `xml:"width,attr"`
+	Height string `xml:"height,attr"`
+	Points string `xml:"points,attr"`
+	Fill   string `xml:"fill,attr"`
+	Stroke string `xml:"stroke,attr"`
+	Text   string `xml:",chardata"`
+}
+
+type svgTestGroup struct {
+	ID        string         `xml:"id,attr"`
+	Lines     []svgTestShape `xml:"line"`
+	Polygons  []svgTestShape `xml:"polygon"`
+	Polylines []svgTestShape `xml:"polyline"`
+	Texts     []svgTestShape `xml:"text"`
+}
+
+type svgTestDocument struct {
+	Width  string         `xml:"width,attr"`
+	Height string         `xml:"height,attr"`
+	Rects  []svgTestShape `xml:"rect"`
+	Groups []svgTestGroup `xml:"g"`
+}
+
+func TestDiagramWidget_WriteSVG(t *testing.T) {
+	test.NewTempApp(t)
+	diagram := makeTestDiagram()
+	diagram.GetDiagramLink("Link1").getBaseDiagramLink().GetMidpointAnchoredText("name").GetDisplayedTextBinding().Set("uses & <owns>")
+
+	var buf bytes.Buffer
+	assert.NoError(t, diagram.WriteSVG(&buf))
+	assert.True(t, strings.HasPrefix(buf.String(), xml.Header))
+	var doc svgTestDocument
+	assert.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+
+	assert.Equal(t, svgNumber(diagram.DesiredSize.Width), doc.Width)
+	assert.Len(t, doc.Rects, 3)
+	node2 := doc.Rects[2]
+	assert.Equal(t, "Node2", node2.ID)
+	assert.Equal(t, "300", node2.X)
+	assert.Equal(t, "150", node2.Y)
+	assert.Equal(t, "88", node2.Width)
+	assert.Equal(t, "48", node2.Height)
+	assert.Equal(t, "#ff0000", node2.Stroke)
+
+	assert.Len(t, doc.Groups, 2)
+	link := diagram.GetDiagramLink("Link1").getBaseDiagramLink()
+	group := doc.Groups[0]
+	assert.Equal(t, "Link1", group.ID)
+	assert.Len(t, group.Lines, 1)
+	source := link.Position().Add(link.GetLinkPoints()[0].Position())
+	target := link.Position().Add(link.GetLinkPoints()[1].Position())
+	assert.Equal(t, svgNumber(source.X), group.Lines[0].X1)
+	assert.Equal(t, svgNumber(source.Y), group.Lines[0].Y1)
+
+	// the solid diamond at the source and the arrowhead whose tip is at the target
+	assert.Len(t, group.Polygons, 1)
+	assert.Equal(t, group.Polygons[0].Stroke, group.Polygons[0].Fill)
+	assert.True(t, strings.HasPrefix(group.Polygons[0].Points, svgNumber(source.X)+","+svgNumber(source.Y)+" "))
+	assert.Len(t, group.Polylines, 1)
+	tip := strings.Fields(group.Polylines[0].Points)[1]
+	assert.Equal(t, svgNumber(target.X)+","+svgNumber(target.Y), tip)
+	assert.Equal(t, "none", group.Polylines[0].Fill)
+
+	assert.Len(t, group.Texts, 1)
+	assert.Equal(t, "uses & <owns>", group.Texts[0].Text)
+	assert.Empty(t, doc.Groups[1].Texts)
+}
+
+func TestSVGPaint(t *testing.T) {
+	assert.Equal(t, ` fill="none"`, svgPaint("fill", nil))
+	assert.Equal(t, ` stroke="#102030"`, svgPaint("stroke", color.NRGBA{R: 0x10, G: 0x20, B: 0x30, A: 0xff}))
+	assert.Equal(t, ` fill="#ff0000" fill-opacity="0.502"`, svgPaint("fill", color.NRGBA{R: 0xff, A: 0x80}))
+	assert.Equal(t, ` fill="none"`, svgPaint("fill", color.Transparent))
+}
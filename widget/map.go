@@ -2,15 +2,19 @@ package widget
 
 import (
 	"image"
+	"image/color"
 	"math"
 	"net/http"
 	"net/url"
-
-	"github.com/nfnt/resize"
+	"slices"
+	"sort"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/driver/mobile"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
@@ -18,27 +22,101 @@ import (
 	"golang.org/x/image/draw"
 )
 
-const tileSize = 256
+const (
+	tileSize    = 256
+	maxZoom     = 19
+	maxLatitude = 85.0511287798 // the edge of the square web mercator world
+
+	scrollZoomSpeed   = 1.0 / 40 // zoom levels per unit of scroll wheel movement
+	zoomAnimationTime = time.Millisecond * 250
+	flyRho            = 1.42 // how far a FlyTo animation zooms out while moving, as suggested by van Wijk and Nieuwenhuizen
+)
 
 // Map widget renders an interactive map using OpenStreetMap tile data.
 type Map struct {
 	widget.BaseWidget
 
+	// OnTapped is called with the location that the user tapped on the map.
+	OnTapped func(lat, lon float64) `json:"-"`
+	// OnViewportChanged is called when the area shown by the map changes, by panning, zooming or resizing.
+	OnViewportChanged func(bounds MapBounds, zoom float64) `json:"-"`
+	// OnMarkerTapped is called when the user taps one of the markers on the map.
+	OnMarkerTapped func(marker MapMarker) `json:"-"`
+	// OnMarkerAdded is called when the user adds a marker by tapping the map while editing.
+	OnMarkerAdded func(marker MapMarker) `json:"-"`
+
+	tiles                  *canvas.Raster
 	pixels                 *image.NRGBA
+	unrotated              *image.NRGBA // the tiles drawn north up, before rotating to the bearing
 	w, h                   int
 	zoom, x, y             int
+	zoomScale              float32 // magnification of the current zoom level, for zoom levels between tile levels
 	offsetX, offsetY       float32 // position offset for accurate positioning
+	bearing                float64 // the direction at the top of the map, in degrees clockwise from north
 	pendingLat, pendingLon float64 // if we tried to calculate scale etc before visible / sized
+	pendingFit             *mapFit // bounds to fit once the map has a size
 
-	cl *http.Client
+	cl       *http.Client
+	cache    MapTileCache
+	provider MapTileProvider // if set this replaces the tileSource, client and cache
+	base     *MapTileLayer   // the layer built from the tile source options
+	layers   []*MapTileLayer // if set these replace the base layer
 
 	tileSource       string         // url to download xyz tiles (example: "https://tile.openstreetmap.org/%d/%d/%d.png")
+	hiDPITileSource  string         // url to use instead of tileSource on high density screens, if set
+	sourceTileSize   int            // the width of tiles from the source, if not 256
 	hideAttribution  bool           // enable copyright attribution
 	attributionLabel string         // label for attribution (example: "OpenStreetMap")
 	attributionURL   string         // url for attribution (example: "https://openstreetmap.org")
 	hideZoomButtons  bool           // enable zoom buttons
 	hideMoveButtons  bool           // enable move map buttons
 	markers          fyne.Container // list of markers to show when in scope
+	markerObjs       []*mapMarker   // all markers, some may be replaced by clusters in the container
+	cluster          bool           // group nearby markers
+	clusterLevel     float64        // the zoom level that markers were last grouped at, or -1 to regroup
+	editing          bool           // tapping the map adds a marker
+
+	attribution *fyne.Container
+	credits     []mapCredit // the attributions currently shown
+
+	showScale        bool
+	scaleUnits       MapUnits
+	scaleBar         *mapScaleBar
+	showCoordinates  bool
+	coordinateFormat MapCoordinateFormat
+	coordinates      *mapCoordinates
+	pointer          *fyne.Position // the mouse position over the map, if any
+	showCompass      bool
+	compass          *mapCompass
+	showOverview     bool
+	overview         *MapOverview   // the overview shown in the corner of the map
+	overviews        []*MapOverview // all overviews following this map
+
+	overlays      []MapOverlay
+	overlayRaster *canvas.Raster
+	overlayPixels *image.RGBA
+
+	zoomAnim *fyne.Animation
+	touches  map[int]fyne.Position // active touch points, used for pinch zoom
+
+	focused       bool
+	focusBorder   *canvas.Rectangle
+	focusedMarker *mapMarker // the marker selected using Tab, if any
+	shiftDown     bool
+
+	lastBounds MapBounds // the viewport last passed to OnViewportChanged
+	lastZoom   float64
+}
+
+type mapFit struct {
+	bounds  MapBounds
+	padding float32
+}
+
+// MapBounds is an area of the map between two latitudes and two longitudes.
+type MapBounds struct {
+	MinLat, MinLon float64 // the south west corner
+	MaxLat, MaxLon float64 // the north east corner
 }
 
 // MapOption configures the provided map with different features.
@@ -62,6 +140,8 @@ func AtZoomLevel(zoom int) MapOption {
 func WithOsmTiles() MapOption {
 	return func(m *Map) {
 		m.tileSource = "https://tile.openstreetmap.org/%d/%d/%d.png"
+		m.provider = nil
+		m.resetBaseLayer()
 		m.attributionLabel = "OpenStreetMap"
 		m.attributionURL = "https://openstreetmap.org"
 		m.hideAttribution = false
@@ -69,9 +149,41 @@ func WithOsmTiles() MapOption {
 }
 
 // WithTileSource configures the map to use a custom tile source.
+// The source is either a URL containing "%d" for the zoom, x and y values in that order,
+// or a template using these placeholders:
+//
+//   - {z}, {x} and {y} are the zoom level and tile position, also accepted as {TileMatrix}, {TileCol} and {TileRow}
+//   - {-y} is the y position counted from the bottom, as used by TMS servers
+//   - {q} or {quadkey} is the Bing maps quadkey of the tile
+//   - {s} is a subdomain from a, b or c, and a range such as {a-d} or {1-4} picks from the given letters or numbers
+//   - {bbox-epsg-3857} is the area of the tile in web mercator metres, as used by WMS servers
 func WithTileSource(tileSource string) MapOption {
 	return func(m *Map) {
 		m.tileSource = tileSource
+		m.provider = nil
+		m.resetBaseLayer()
+	}
+}
+
+// WithHiDPITileSource configures the map to use a different tile source on high density screens.
+// This is usually the retina or "@2x" version of the tile source, which has tiles of 512 pixels
+// covering the same area as the standard 256 pixel tiles.
+func WithHiDPITileSource(tileSource string) MapOption {
+	return func(m *Map) {
+		m.hiDPITileSource = tileSource
+		m.provider = nil
+		m.resetBaseLayer()
+	}
+}
+
+// WithTileSize configures the width in pixels of the tiles from the tile source, the default is 256.
+// Larger tiles cover the area of several standard tiles, for example each 512 pixel tile at zoom level 1
+// covers the area of four 256 pixel tiles at zoom level 2.
+// These are shown without any scaling on screens with double pixel density.
+func WithTileSize(size int) MapOption {
+	return func(m *Map) {
+		m.sourceTileSize = size
+		m.resetBaseLayer()
 	}
 }
 
@@ -102,6 +214,16 @@ func WithScrollButtons(enable bool) MapOption {
 func WithHTTPClient(client *http.Client) MapOption {
 	return func(m *Map) {
 		m.cl = client
+		m.resetBaseLayer()
+	}
+}
+
+// WithTileCache configures the map to store downloaded tiles in the provided cache.
+// By default tiles are kept in a limited size in-memory cache.
+func WithTileCache(cache MapTileCache) MapOption {
+	return func(m *Map) {
+		m.cache = cache
+		m.resetBaseLayer()
 	}
 }
 
@@ -112,9 +234,34 @@ func WithMapMarkers(objs []MapMarker) MapOption {
 	}
 }
 
+// WithMarkerClustering enables or disables grouping of nearby markers.
+// When enabled markers that are close together at the current zoom level are shown as a badge with a count,
+// tapping the badge will zoom in to show the markers it contains.
+func WithMarkerClustering(enable bool) MapOption {
+	return func(m *Map) {
+		m.cluster = enable
+		m.clusterLevel = -1
+		m.updateMarkers()
+	}
+}
+
+// WithMarkerEditing enables or disables adding markers by tapping the map. See SetEditing.
+func WithMarkerEditing(enable bool) MapOption {
+	return func(m *Map) {
+		m.editing = enable
+	}
+}
+
+// WithMapOverlays configures the map to draw a list of overlays above the map tiles.
+func WithMapOverlays(overlays []MapOverlay) MapOption {
+	return func(m *Map) {
+		m.SetOverlays(overlays)
+	}
+}
+
 // NewMap creates a new instance of the map widget.
 func NewMap() *Map {
-	m := &Map{cl: &http.Client{}}
+	m := &Map{cl: &http.Client{}, cache: NewMemoryTileCache(defaultTileCacheSize), zoomScale: 1}
 	WithOsmTiles()(m)
 	m.ExtendBaseWidget(m)
 	return m
@@ -160,12 +307,16 @@ func (m *Map) PanWest() {
 	m.Refresh()
 }
 
-// https://wiki.openstreetmap.org/wiki/Slippy_map_tilenames#Mathematics
+// PositionOf returns the position within the map of a latitude and longitude.
+// The position may be outside the size of the map if the location is not currently visible.
+func (m *Map) PositionOf(lat, lon float64) fyne.Position {
+	return m.toScreen(m.getPosFromLatLon(lat, lon))
+}
+
+// getPosFromLatLon returns the position of a location within the map before it is rotated to the bearing.
 func (m *Map) getPosFromLatLon(lat, lon float64) fyne.Position {
 	n := float64(int(1) << m.zoom)
-	xTile := (lon + 180.0) / 360.0 * n
-	latRad := lat * math.Pi / 180.0
-	yTile := (1.0 - math.Log(math.Tan(latRad)+1.0/math.Cos(latRad))/math.Pi) / 2.0 * n
+	xTile, yTile := latLonToTile(lat, lon, m.zoom)
 
 	mx := m.x + int(float32(n)/2-0.5)
 	my := m.y + int(float32(n)/2-0.5)
@@ -178,9 +329,32 @@ func (m *Map) getPosFromLatLon(lat, lon float64) fyne.Position {
 	dpX := (size.Width+mid)/2 + float32(xTile-float64(mx))*tileSize + m.offsetX
 	dpY := (size.Height+mid)/2 + float32(yTile-float64(my))*tileSize + m.offsetY
 
+	// between zoom levels the map is magnified around the middle
+	dpX = size.Width/2 + (dpX-size.Width/2)*m.zoomScale
+	dpY = size.Height/2 + (dpY-size.Height/2)*m.zoomScale
 	return fyne.NewPos(dpX, dpY)
 }
 
+// LatLonAt returns the latitude and longitude of a position within the map.
+func (m *Map) LatLonAt(pos fyne.Position) (float64, float64) {
+	pos = m.fromScreen(pos)
+	n := float64(int(1) << m.zoom)
+	mx := float64(m.x + int(float32(n)/2-0.5))
+	my := float64(m.y + int(float32(n)/2-0.5))
+
+	size := m.Size()
+	mid := float32(-tileSize * 2)
+	if m.zoom == 0 {
+		mid = -tileSize
+	}
+	x := size.Width/2 + (pos.X-size.Width/2)/m.zoomScale
+	y := size.Height/2 + (pos.Y-size.Height/2)/m.zoomScale
+
+	xTile := mx + float64(x-(size.Width+mid)/2-m.offsetX)/tileSize
+	yTile := my + float64(y-(size.Height+mid)/2-m.offsetY)/tileSize
+	return tileToLatLon(xTile, yTile, m.zoom)
+}
+
 // PanToLatLon moves the center of the map to the requested latitude and longitude.
 func (m *Map) PanToLatLon(lat, lon float64) {
 	if m.Size().IsZero() { // the calculations don't work when no size
@@ -190,9 +364,7 @@ func (m *Map) PanToLatLon(lat, lon float64) {
 	}
 
 	n := float64(int(1) << m.zoom)
-	xTile := (lon + 180.0) / 360.0 * n
-	latRad := lat * math.Pi / 180.0
-	yTile := (1.0 - math.Log(math.Tan(latRad)+1.0/math.Cos(latRad))/math.Pi) / 2.0 * n
+	xTile, yTile := latLonToTile(lat, lon, m.zoom)
 
 	m.x = int(math.Floor(xTile)) - int(float32(n)/2-0.5)
 	m.y = int(math.Floor(yTile)) - int(float32(n)/2-0.5)
@@ -201,12 +373,57 @@ func (m *Map) PanToLatLon(lat, lon float64) {
 
 	pos := m.getPosFromLatLon(lat, lon)
 	size := m.Size()
-	m.offsetX = size.Width/2 - pos.X
-	m.offsetY = size.Height/2 - pos.Y
+	m.offsetX = (size.Width/2 - pos.X) / m.zoomScale
+	m.offsetY = (size.Height/2 - pos.Y) / m.zoomScale
 	m.Refresh()
 }
 
-// https://wiki.openstreetmap.org/wiki/Slippy_map_tilenames#Mathematics
+// FitBounds zooms and pans the map so that the area between two corners is visible.
+// The padding is the space that should be left around the area, in the same units as the map size.
+// The closest whole zoom level that shows the whole area is used, so that tiles are drawn at their natural size.
+func (m *Map) FitBounds(minLat, minLon, maxLat, maxLon float64, padding float32) {
+	if m.Size().IsZero() { // the calculations don't work when no size
+		m.pendingFit = &mapFit{bounds: MapBounds{MinLat: minLat, MinLon: minLon, MaxLat: maxLat, MaxLon: maxLon},
+			padding: padding}
+		return
+	}
+
+	size := m.Size()
+	width, height := float64(size.Width-padding*2), float64(size.Height-padding*2)
+
+	zoom := maxZoom
+	for ; zoom > 0; zoom-- {
+		left, top := latLonToTile(maxLat, minLon, zoom)
+		right, bottom := latLonToTile(minLat, maxLon, zoom)
+		if w, h := m.rotatedSize((right-left)*tileSize, (bottom-top)*tileSize); w <= width && h <= height {
+			break
+		}
+	}
+
+	left, top := latLonToTile(maxLat, minLon, zoom)
+	right, bottom := latLonToTile(minLat, maxLon, zoom)
+	lat, lon := tileToLatLon((left+right)/2, (top+bottom)/2, zoom)
+	m.stopZoomAnimation()
+	m.zoom, m.zoomScale = zoom, 1
+	m.PanToLatLon(lat, lon)
+}
+
+// VisibleBounds returns the area currently shown by the map, limited to the edges of the world.
+// When the map is rotated this is the smallest area that contains all of the map corners.
+func (m *Map) VisibleBounds() MapBounds {
+	size := m.Size()
+	minLat, minLon := math.Inf(1), math.Inf(1)
+	maxLat, maxLon := math.Inf(-1), math.Inf(-1)
+	for _, corner := range []fyne.Position{{}, {X: size.Width}, {Y: size.Height}, {X: size.Width, Y: size.Height}} {
+		lat, lon := m.LatLonAt(corner)
+		minLat, maxLat = math.Min(minLat, lat), math.Max(maxLat, lat)
+		minLon, maxLon = math.Min(minLon, lon), math.Max(maxLon, lon)
+	}
+
+	return MapBounds{MinLat: math.Max(-maxLatitude, minLat), MinLon: math.Max(-180, minLon),
+		MaxLat: math.Min(maxLatitude, maxLat), MaxLon: math.Min(180, maxLon)}
+}
+
 func (m *Map) getCenterLatLon() (float64, float64) {
 	n := float64(int(1) << m.zoom)
 	mx := float64(m.x + int(float32(n)/2-0.5))
@@ -220,36 +437,99 @@ func (m *Map) getCenterLatLon() (float64, float64) {
 	xTile := mx + (-mid/2-float64(m.offsetX))/tileSize
 	yTile := my + (-mid/2-float64(m.offsetY))/tileSize
 
-	lon := xTile/n*360.0 - 180.0
-	latRad := math.Atan(math.Sinh(math.Pi * (1.0 - 2.0*yTile/n)))
-	lat := latRad * 180.0 / math.Pi
-
-	return lat, lon
+	return tileToLatLon(xTile, yTile, m.zoom)
 }
 
 // SetMarkers updates the list of markers to show on the map.
 func (m *Map) SetMarkers(markers []MapMarker) {
-	objs := make([]fyne.CanvasObject, len(markers))
+	m.focusMarker(nil)
+	m.markerObjs = make([]*mapMarker, len(markers))
 	for n, marker := range markers {
-		objs[n] = newMapMarker(marker)
+		m.markerObjs[n] = m.newMarker(marker)
 	}
-	m.markers.Objects = objs
+	m.clusterLevel = -1
+	m.updateMarkers()
+	m.markers.Refresh()
+}
+
+// AddMarker adds a marker to those shown on the map.
+func (m *Map) AddMarker(marker MapMarker) {
+	m.markerObjs = append(m.markerObjs, m.newMarker(marker))
+	m.clusterLevel = -1
+	m.updateMarkers()
 	m.markers.Refresh()
 }
 
+// Editing returns true if tapping the map adds a marker.
+func (m *Map) Editing() bool {
+	return m.editing
+}
+
+// SetEditing turns marker editing on or off.
+// While editing, tapping the map adds a draggable marker at that location and calls OnMarkerAdded.
+func (m *Map) SetEditing(editing bool) {
+	m.editing = editing
+}
+
+// Refresh updates the map display, grouping markers again if clustering is on and the zoom level changed.
+func (m *Map) Refresh() {
+	m.updateMarkers()
+	if m.attribution != nil {
+		m.updateAttribution()
+	}
+	m.BaseWidget.Refresh()
+	m.updateControls()
+	m.viewportChanged()
+}
+
+// SetOverlays updates the list of shapes drawn above the map tiles.
+// Overlays are drawn in order, so later overlays appear above earlier ones.
+func (m *Map) SetOverlays(overlays []MapOverlay) {
+	m.overlays = overlays
+	if m.overlayRaster != nil {
+		m.overlayRaster.Refresh()
+	}
+}
+
 func (m *Map) Resize(s fyne.Size) {
 	m.BaseWidget.Resize(s)
 	if m.pendingLat != 0 || m.pendingLon != 0 {
 		m.PanToLatLon(m.pendingLat, m.pendingLon)
 		m.pendingLat, m.pendingLon = 0, 0
 	}
+	if fit := m.pendingFit; fit != nil && !s.IsZero() {
+		m.pendingFit = nil
+		m.FitBounds(fit.bounds.MinLat, fit.bounds.MinLon, fit.bounds.MaxLat, fit.bounds.MaxLon, fit.padding)
+	}
+	m.updateControls()
+	m.viewportChanged()
+}
+
+// Tapped is called when the user taps the map, it passes the location to OnTapped.
+// If the map is editing a marker is also added at the location.
+func (m *Map) Tapped(ev *fyne.PointEvent) {
+	m.requestFocus()
+	lat, lon := m.LatLonAt(ev.Position)
+	if m.editing {
+		marker := &CustomMapMarker{Latitude: lat, Longitude: lon, Draggable: true}
+		m.AddMarker(marker)
+		if m.OnMarkerAdded != nil {
+			m.OnMarkerAdded(marker)
+		}
+	}
+
+	if m.OnTapped != nil {
+		m.OnTapped(lat, lon)
+	}
 }
 
 // Zoom sets the zoom level to a specific value, between 0 and 19.
 func (m *Map) Zoom(zoom int) {
-	if zoom < 0 || zoom > 19 {
+	if zoom < 0 || zoom > maxZoom {
 		return
 	}
+	m.stopZoomAnimation()
+	m.zoomScale = 1
 	delta := zoom - m.zoom
 	if delta > 0 {
 		for i := 0; i < delta; i++ {
@@ -264,32 +544,135 @@ func (m *Map) Zoom(zoom int) {
 }
 
 // ZoomIn steps the scale of this map to be one step zoomed in.
+// The change is made immediately, use ZoomInAnimated to animate it.
 func (m *Map) ZoomIn() {
-	if m.zoom >= 19 {
-		return
+	if level, ok := m.zoomInLevel(); ok {
+		m.SetZoomLevel(level)
+	}
+}
+
+// ZoomInAnimated steps the scale of this map to be one step zoomed in, as ZoomIn does.
+// If the map is visible the change is animated, so the new zoom level is only reached once the animation ends.
+func (m *Map) ZoomInAnimated() {
+	if level, ok := m.zoomInLevel(); ok {
+		m.animateZoom(level)
 	}
-	m.zoomInStep()
-	m.Refresh()
 }
 
 // ZoomOut steps the scale of this map to be one step zoomed out.
+// The change is made immediately, use ZoomOutAnimated to animate it.
 func (m *Map) ZoomOut() {
-	if m.zoom <= 0 {
-		return
+	if level, ok := m.zoomOutLevel(); ok {
+		m.SetZoomLevel(level)
 	}
-	m.zoomOutStep()
+}
+
+// ZoomOutAnimated steps the scale of this map to be one step zoomed out, as ZoomOut does.
+// If the map is visible the change is animated, so the new zoom level is only reached once the animation ends.
+func (m *Map) ZoomOutAnimated() {
+	if level, ok := m.zoomOutLevel(); ok {
+		m.animateZoom(level)
+	}
+}
+
+// ZoomLevel returns the current zoom level, which may be between whole levels after scrolling or pinching.
+func (m *Map) ZoomLevel() float64 {
+	return float64(m.zoom) + math.Log2(float64(m.zoomScale))
+}
+
+// SetZoomLevel sets the zoom level to a value between 0 and 19, keeping the middle of the map in place.
+// Levels between whole numbers show the tiles of the nearest level scaled to fit.
+func (m *Map) SetZoomLevel(level float64) {
+	m.stopZoomAnimation()
+	m.setZoomLevel(level)
 	m.Refresh()
 }
 
 // Dragged handles drag events to pan the map smoothly.
 func (m *Map) Dragged(ev *fyne.DragEvent) {
-	m.offsetX += ev.Dragged.DX
-	m.offsetY += ev.Dragged.DY
+	if len(m.touches) > 1 { // pinch zoom handles the movement
+		return
+	}
+	m.stopZoomAnimation()
+	m.panBy(ev.Dragged.DX, ev.Dragged.DY)
+	m.Refresh()
+}
 
-	m.wrapOffset()
+// Scrolled zooms the map in or out, keeping the location under the pointer in place.
+func (m *Map) Scrolled(ev *fyne.ScrollEvent) {
+	m.stopZoomAnimation()
+	m.setZoomLevelAt(m.ZoomLevel()+float64(ev.Scrolled.DY)*scrollZoomSpeed, ev.Position)
 	m.Refresh()
 }
 
+// MouseIn is called when the mouse pointer enters the map.
+func (m *Map) MouseIn(ev *desktop.MouseEvent) {
+	m.MouseMoved(ev)
+}
+
+// MouseMoved is called when the mouse pointer moves over the map, updating the coordinate readout.
+func (m *Map) MouseMoved(ev *desktop.MouseEvent) {
+	pos := ev.Position
+	m.pointer = &pos
+	m.updateControls()
+}
+
+// MouseOut is called when the mouse pointer leaves the map.
+func (m *Map) MouseOut() {
+	m.pointer = nil
+	m.updateControls()
+}
+
+// TouchDown is called when a finger touches the map on a mobile device.
+func (m *Map) TouchDown(ev *mobile.TouchEvent) {
+	if m.touches == nil {
+		m.touches = make(map[int]fyne.Position)
+	}
+	m.touches[ev.ID] = ev.Position
+}
+
+// TouchMoved is called when a finger moves on a mobile device.
+// With two fingers down the map is zoomed by the change in distance between them.
+func (m *Map) TouchMoved(ev *mobile.TouchEvent) {
+	if _, ok := m.touches[ev.ID]; !ok {
+		return
+	}
+	if len(m.touches) != 2 {
+		m.touches[ev.ID] = ev.Position
+		return
+	}
+
+	oldMid, oldDist := m.pinch()
+	m.touches[ev.ID] = ev.Position
+	mid, dist := m.pinch()
+	if oldDist < 1 || dist < 1 {
+		return
+	}
+
+	m.stopZoomAnimation()
+	m.setZoomLevelAt(m.ZoomLevel()+math.Log2(float64(dist/oldDist)), oldMid)
+	m.panBy(mid.X-oldMid.X, mid.Y-oldMid.Y)
+	m.Refresh()
+}
+
+// TouchUp is called when a finger is lifted from the map on a mobile device.
+func (m *Map) TouchUp(ev *mobile.TouchEvent) {
+	delete(m.touches, ev.ID)
+}
+
+// TouchCancel is called when a touch on the map is interrupted on a mobile device.
+func (m *Map) TouchCancel(ev *mobile.TouchEvent) {
+	delete(m.touches, ev.ID)
+}
+
+// panBy moves the map by a distance across the screen.
+func (m *Map) panBy(dx, dy float32) {
+	dx, dy = rotateBy(dx, dy, m.bearing)
+	m.offsetX += dx / m.zoomScale
+	m.offsetY += dy / m.zoomScale
+	m.wrapOffset()
+}
+
 func (m *Map) wrapOffset() {
 	tile64 := float64(tileSize)
 	offX64 := float64(m.offsetX)
@@ -318,11 +701,20 @@ func (m *Map) DragEnd() {
 // CreateRenderer returns the renderer for this widget.
 // A map renderer is simply the map Raster with user interface elements overlaid.
 func (m *Map) CreateRenderer() fyne.WidgetRenderer {
-	var zoom fyne.CanvasObject
+	var buttons []fyne.CanvasObject
+	m.compass = nil
+	if m.showCompass {
+		m.compass = newMapCompass(m.resetBearing)
+		buttons = append(buttons, m.compass)
+	}
 	if !m.hideZoomButtons {
-		zoom = container.NewVBox(
-			newMapButton(theme.ZoomInIcon(), m.ZoomIn),
-			newMapButton(theme.ZoomOutIcon(), m.ZoomOut))
+		buttons = append(buttons,
+			newMapButton(theme.ZoomInIcon(), m.ZoomInAnimated),
+			newMapButton(theme.ZoomOutIcon(), m.ZoomOutAnimated))
+	}
+	var zoom fyne.CanvasObject
+	if len(buttons) > 0 {
+		zoom = container.NewVBox(buttons...)
 	}
 
 	var move fyne.CanvasObject
@@ -335,21 +727,45 @@ func (m *Map) CreateRenderer() fyne.WidgetRenderer {
 		move = container.NewVBox(buttonLayout)
 	}
 
-	var copyright fyne.CanvasObject
-	if !m.hideAttribution {
-		license, _ := url.Parse(m.attributionURL)
-		link := widget.NewHyperlink(m.attributionLabel, license)
-		copyright = container.NewHBox(layout.NewSpacer(), link)
+	m.attribution = container.NewHBox()
+	m.credits = nil
+	m.updateAttribution()
+
+	var controls []fyne.CanvasObject
+	m.scaleBar, m.coordinates = nil, nil
+	if m.showScale {
+		m.scaleBar = newMapScaleBar(m.scaleUnits)
+		controls = append(controls, m.scaleBar)
 	}
+	if m.showCoordinates {
+		m.coordinates = newMapCoordinates(m.coordinateFormat)
+		controls = append(controls, m.coordinates)
+	}
+	m.updateControls()
 
-	overlay := container.NewBorder(nil, copyright, move, zoom)
+	var bottom fyne.CanvasObject = container.NewBorder(nil, nil, container.NewHBox(controls...), nil, m.attribution)
+	if m.showOverview {
+		if m.overview == nil {
+			m.overview = NewMapOverview(m)
+		}
+		bottom = container.NewVBox(container.NewHBox(layout.NewSpacer(), m.overview), bottom)
+	}
+	overlay := container.NewBorder(nil, bottom, move, zoom)
+
+	m.markers.Layout = &mapMarkerLayout{m.PositionOf}
+	m.tiles = canvas.NewRaster(m.draw)
+	m.overlayRaster = canvas.NewRaster(m.drawOverlays)
 
-	m.markers.Layout = &mapMarkerLayout{m.getPosFromLatLon}
+	m.focusBorder = canvas.NewRectangle(color.Transparent)
+	m.focusBorder.StrokeWidth = theme.InputBorderSize() * 2
+	m.updateFocus()
 
 	c := container.NewStack(
-		canvas.NewRaster(m.draw),
+		m.tiles,
+		m.overlayRaster,
 		&m.markers,
 		container.NewPadded(overlay),
+		m.focusBorder,
 	)
 
 	return widget.NewSimpleRenderer(c)
@@ -362,9 +778,30 @@ func (m *Map) draw(w, h int) image.Image {
 	}
 	if m.w != w || m.h != h {
 		m.pixels = image.NewNRGBA(image.Rect(0, 0, w, h))
+		m.w, m.h = w, h
+	} else {
+		draw.Draw(m.pixels, m.pixels.Bounds(), image.Transparent, image.Point{}, draw.Src)
+	}
+
+	if m.bearing == 0 {
+		m.drawTiles(m.pixels, scale)
+	} else {
+		m.drawRotated(scale)
+	}
+	return m.pixels
+}
+
+// drawTiles draws the visible tiles into an image, north up, with the middle of the map in the middle of the image.
+func (m *Map) drawTiles(img *image.NRGBA, scale float32) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	layers := m.tileLayers()
+	for _, l := range layers {
+		if l.visible() {
+			l.prepare(scale > 1, m.tileLoaded)
+		}
 	}
 
-	tileSize := int(tileSize * math.Round(float64(scale)))
+	tileSize := int(math.Round(tileSize * float64(scale)))
 	midTileX := (w - tileSize*2) / 2
 	midTileY := (h - tileSize*2) / 2
 	if m.zoom == 0 {
@@ -375,32 +812,418 @@ func (m *Map) draw(w, h int) image.Image {
 	count := 1 << m.zoom
 	mx := m.x + int(float32(count)/2-0.5)
 	my := m.y + int(float32(count)/2-0.5)
-	firstTileX := mx - int(math.Ceil(float64(midTileX)/float64(tileSize))) - 1
-	firstTileY := my - int(math.Ceil(float64(midTileY)/float64(tileSize))) - 1
+	// when zoomed out between levels more tiles are needed to fill the space
+	spanX := int(math.Ceil(float64(w)/float64(m.zoomScale)/float64(tileSize)/2)) + 2
+	spanY := int(math.Ceil(float64(h)/float64(m.zoomScale)/float64(tileSize)/2)) + 2
 
-	for x := firstTileX; (x-firstTileX)*tileSize <= w+tileSize*2; x++ {
-		for y := firstTileY; (y-firstTileY)*tileSize <= h+tileSize*2; y++ {
+	var visible []MapTileKey
+	for x := mx - spanX; x <= mx+spanX; x++ {
+		for y := my - spanY; y <= my+spanY; y++ {
 			if x < 0 || y < 0 || x >= int(count) || y >= int(count) {
 				continue
 			}
+			visible = append(visible, MapTileKey{Zoom: m.zoom, X: x, Y: y})
+		}
+	}
+	for _, l := range layers {
+		if l.visible() {
+			l.loader.reserve(len(visible))
+		}
+	}
+	// request the tiles closest to the middle first
+	sort.Slice(visible, func(i, j int) bool {
+		return tileDistance(visible[i], mx, my) < tileDistance(visible[j], mx, my)
+	})
+
+	wanted := make(map[MapTileKey]bool, len(visible))
+	for _, key := range visible {
+		pos := image.Pt(midTileX+(key.X-mx)*tileSize+int(m.offsetX*scale),
+			midTileY+(key.Y-my)*tileSize+int(m.offsetY*scale))
+		bounds := m.scaleTileBounds(image.Rectangle{Min: pos, Max: pos.Add(image.Pt(tileSize, tileSize))}, w, h)
+		if !bounds.Overlaps(img.Bounds()) {
+			continue
+		}
 
-			src, err := getTile(m.tileSource, x, y, m.zoom, m.cl)
-			if err != nil {
-				fyne.LogError("tile fetch error", err)
+		wanted[key] = true
+		first := true
+		for _, l := range layers {
+			if !l.visible() {
 				continue
 			}
+			l.drawTile(img, key, bounds, m.zoomScale != 1, first)
+			first = false
+		}
+	}
+	for _, l := range layers {
+		if l.visible() {
+			l.retain(wanted)
+		} else {
+			l.retain(nil)
+		}
+	}
+}
+
+func (m *Map) drawOverlays(w, h int) image.Image {
+	if m.overlayPixels == nil || m.overlayPixels.Bounds().Dx() != w || m.overlayPixels.Bounds().Dy() != h {
+		m.overlayPixels = image.NewRGBA(image.Rect(0, 0, w, h))
+	} else {
+		draw.Draw(m.overlayPixels, m.overlayPixels.Bounds(), image.Transparent, image.Point{}, draw.Src)
+	}
+
+	scale := float32(1)
+	if s := m.Size(); s.Width > 0 {
+		scale = float32(w) / s.Width
+	}
+	proj := &mapProjection{m: m, scale: scale}
+	for _, o := range m.overlays {
+		o.Draw(m.overlayPixels, proj)
+	}
+	return m.overlayPixels
+}
+
+// scaleTileBounds applies the magnification between zoom levels to the area of a tile in an image of size w, h.
+func (m *Map) scaleTileBounds(r image.Rectangle, w, h int) image.Rectangle {
+	if m.zoomScale == 1 {
+		return r
+	}
+
+	scale := func(v, mid int) int {
+		return int(math.Round(float64(mid) + float64(v-mid)*float64(m.zoomScale)))
+	}
+	return image.Rect(scale(r.Min.X, w/2), scale(r.Min.Y, h/2), scale(r.Max.X, w/2), scale(r.Max.Y, h/2))
+}
+
+func (m *Map) tileLoaded() {
+	fyne.Do(func() {
+		if m.tiles != nil {
+			m.tiles.Refresh()
+		}
+	})
+}
+
+// latLonToTile converts a location into fractional tile coordinates at a zoom level.
+// https://wiki.openstreetmap.org/wiki/Slippy_map_tilenames#Mathematics
+func latLonToTile(lat, lon float64, zoom int) (float64, float64) {
+	n := float64(int(1) << zoom)
+	xTile := (lon + 180.0) / 360.0 * n
+	latRad := lat * math.Pi / 180.0
+	yTile := (1.0 - math.Log(math.Tan(latRad)+1.0/math.Cos(latRad))/math.Pi) / 2.0 * n
+	return xTile, yTile
+}
 
-			pos := image.Pt(midTileX+(x-mx)*tileSize+int(m.offsetX*scale),
-				midTileY+(y-my)*tileSize+int(m.offsetY*scale))
-			scaled := src
-			if scale > 1 {
-				scaled = resize.Resize(uint(tileSize), uint(tileSize), src, resize.Lanczos2)
+// tileToLatLon converts fractional tile coordinates at a zoom level into a location.
+func tileToLatLon(xTile, yTile float64, zoom int) (float64, float64) {
+	n := float64(int(1) << zoom)
+	lon := xTile/n*360.0 - 180.0
+	latRad := math.Atan(math.Sinh(math.Pi * (1.0 - 2.0*yTile/n)))
+	return latRad * 180.0 / math.Pi, lon
+}
+
+// tilePart returns the part of an image area showing a tile at zoom level that covers a smaller tile at a higher level.
+func tilePart(r image.Rectangle, zoom int, key MapTileKey) image.Rectangle {
+	levels := key.Zoom - zoom
+	if levels <= 0 {
+		return r
+	}
+
+	w, h := r.Dx()>>levels, r.Dy()>>levels
+	mask := 1<<levels - 1
+	origin := r.Min.Add(image.Pt((key.X&mask)*w, (key.Y&mask)*h))
+	return image.Rectangle{Min: origin, Max: origin.Add(image.Pt(w, h))}
+}
+
+// tileDistance returns the squared distance between a tile and the tile at x, y.
+func tileDistance(key MapTileKey, x, y int) int {
+	dx, dy := key.X-x, key.Y-y
+	return dx*dx + dy*dy
+}
+
+func (m *Map) updateMarkers() {
+	level := m.ZoomLevel()
+	if !m.cluster || m.zoom >= maxZoom {
+		if len(m.markers.Objects) == len(m.markerObjs) && m.clusterLevel == level {
+			return
+		}
+		objs := make([]fyne.CanvasObject, len(m.markerObjs))
+		for n, marker := range m.markerObjs {
+			objs[n] = marker
+		}
+		m.markers.Objects = objs
+		m.clusterLevel = level
+		return
+	}
+	if m.clusterLevel == level {
+		return
+	}
+
+	m.markers.Objects = clusterMarkers(m.markerObjs, m.zoom, float64(m.zoomScale), m.zoomToCluster)
+	m.clusterLevel = level
+}
+
+type mapCredit struct {
+	label, url string
+}
+
+// updateAttribution shows the credits for the visible tile layers, if they have changed.
+func (m *Map) updateAttribution() {
+	var credits []mapCredit
+	if !m.hideAttribution {
+		if len(m.layers) == 0 {
+			credits = append(credits, mapCredit{m.attributionLabel, m.attributionURL})
+		}
+		for _, l := range m.layers {
+			if !l.Hidden && l.AttributionLabel != "" {
+				credits = append(credits, mapCredit{l.AttributionLabel, l.AttributionURL})
 			}
-			draw.Copy(m.pixels, pos, scaled, image.Rect(0, 0, tileSize, tileSize), draw.Over, nil)
 		}
 	}
+	if slices.Equal(credits, m.credits) {
+		return
+	}
 
-	return m.pixels
+	m.credits = credits
+	if len(credits) == 0 {
+		m.attribution.Objects = nil
+		m.attribution.Refresh()
+		return
+	}
+	objs := []fyne.CanvasObject{layout.NewSpacer()}
+	for _, c := range credits {
+		link, _ := url.Parse(c.url)
+		objs = append(objs, widget.NewHyperlink(c.label, link))
+	}
+	m.attribution.Objects = objs
+	m.attribution.Refresh()
+}
+
+func (m *Map) newMarker(marker MapMarker) *mapMarker {
+	obj := newMapMarker(marker)
+	obj.onTapped = m.markerTapped
+	obj.onDragged = m.markerDragged
+	obj.onDragEnd = m.markerDragEnd
+	return obj
+}
+
+// markerDragged moves a draggable marker with the pointer, other markers pass the drag to the map.
+func (m *Map) markerDragged(marker *mapMarker, ev *fyne.DragEvent) {
+	d, ok := marker.obj.(MapMarkerDraggable)
+	if !ok || !d.MarkerDraggable() {
+		m.Dragged(ev)
+		return
+	}
+
+	pos := m.PositionOf(d.Lat(), d.Lon()).AddXY(ev.Dragged.DX, ev.Dragged.DY)
+	d.MarkerDragged(m.LatLonAt(pos))
+	m.markers.Refresh()
+}
+
+func (m *Map) markerDragEnd(marker *mapMarker) {
+	d, ok := marker.obj.(MapMarkerDraggable)
+	if !ok || !d.MarkerDraggable() {
+		m.DragEnd()
+		return
+	}
+
+	d.MarkerDragEnd(d.Lat(), d.Lon())
+	m.clusterLevel = -1 // the marker may have moved in or out of a group
+	m.Refresh()
+}
+
+// updateControls shows the current scale, pointer location and bearing, if those controls are enabled,
+// and moves any overviews to follow the map.
+func (m *Map) updateControls() {
+	size := m.Size()
+	if size.IsZero() {
+		return
+	}
+
+	if m.scaleBar != nil {
+		lat, _ := m.LatLonAt(fyne.NewPos(size.Width/2, size.Height/2))
+		worldSize := float64(tileSize) * math.Exp2(m.ZoomLevel())
+		m.scaleBar.setScale(earthCircumference * math.Cos(lat*math.Pi/180) / worldSize)
+	}
+	if m.coordinates != nil {
+		pos := fyne.NewPos(size.Width/2, size.Height/2)
+		if m.pointer != nil {
+			pos = *m.pointer
+		}
+		m.coordinates.setLatLon(m.LatLonAt(pos))
+	}
+	if m.compass != nil {
+		m.compass.setBearing(m.bearing)
+	}
+	for _, o := range m.overviews {
+		o.update()
+	}
+}
+
+func (m *Map) markerTapped(marker MapMarker) {
+	if m.OnMarkerTapped != nil {
+		m.OnMarkerTapped(marker)
+	}
+}
+
+// viewportChanged calls OnViewportChanged if the visible area has moved since it was last called.
+func (m *Map) viewportChanged() {
+	if m.OnViewportChanged == nil || m.Size().IsZero() {
+		return
+	}
+
+	bounds, zoom := m.VisibleBounds(), m.ZoomLevel()
+	if bounds == m.lastBounds && zoom == m.lastZoom {
+		return
+	}
+	m.lastBounds, m.lastZoom = bounds, zoom
+	m.OnViewportChanged(bounds, zoom)
+}
+
+func (m *Map) zoomToCluster(c *mapMarkerCluster) {
+	level := m.ZoomLevel()
+	m.FitBounds(c.minLat, c.minLon, c.maxLat, c.maxLon, clusterRadius)
+	if m.ZoomLevel() <= level { // markers at the same location need zooming in further to separate
+		m.SetZoomLevel(level)
+		m.PanToLatLon(c.lat, c.lon)
+		m.ZoomIn()
+	}
+}
+
+func (m *Map) animateZoom(level float64) {
+	m.stopZoomAnimation()
+	if m.tiles == nil || !fyne.CurrentApp().Settings().ShowAnimations() { // not visible yet
+		m.SetZoomLevel(level)
+		return
+	}
+
+	start := m.ZoomLevel()
+	m.zoomAnim = fyne.NewAnimation(zoomAnimationTime, func(done float32) {
+		m.setZoomLevel(start + (level-start)*float64(done))
+		m.Refresh()
+	})
+	m.zoomAnim.Start()
+}
+
+// FlyTo moves the map to a location and zoom level, animating along a path that zooms out while travelling
+// so that the user can see where the map is moving to. Dragging, scrolling or zooming the map stops the animation.
+func (m *Map) FlyTo(lat, lon, zoom float64, duration time.Duration) {
+	m.stopZoomAnimation()
+	zoom = math.Max(0, math.Min(maxZoom, zoom))
+	size := m.Size()
+	if m.tiles == nil || size.IsZero() || duration <= 0 || !fyne.CurrentApp().Settings().ShowAnimations() {
+		m.setView(lat, lon, zoom)
+		return
+	}
+
+	// positions are compared across a world of the size at the start zoom level
+	startZoom := m.ZoomLevel()
+	world := tileSize * math.Exp2(startZoom)
+	startLat, startLon := m.LatLonAt(fyne.NewPos(size.Width/2, size.Height/2))
+	x0, y0 := latLonToTile(startLat, startLon, 0)
+	x1, y1 := latLonToTile(lat, lon, 0)
+	dist := math.Hypot(x1-x0, y1-y0) * world
+	w0 := float64(fyne.Max(size.Width, size.Height))
+	w1 := w0 / math.Exp2(zoom-startZoom)
+
+	m.zoomAnim = fyne.NewAnimation(duration, func(done float32) {
+		if done >= 1 {
+			m.setView(lat, lon, zoom)
+			return
+		}
+
+		u, w := flyPath(w0, w1, dist, float64(done))
+		f := 0.0
+		if dist > 0 {
+			f = u / dist
+		}
+		midLat, midLon := tileToLatLon(x0+(x1-x0)*f, y0+(y1-y0)*f, 0)
+		m.setView(midLat, midLon, startZoom+math.Log2(w0/w))
+	})
+	m.zoomAnim.Start()
+}
+
+// flyPath returns the distance travelled and the width of the view at a point through a FlyTo animation,
+// where w0 and w1 are the widths of the view at the start and end and u1 is the distance to travel.
+// See "Smooth and efficient zooming and panning" by Jarke J. van Wijk and Wim A.A. Nieuwenhuizen.
+func flyPath(w0, w1, u1, t float64) (u, w float64) {
+	if u1 < 1e-6 { // only zooming
+		return 0, w0 * math.Pow(w1/w0, t)
+	}
+
+	rho2 := flyRho * flyRho
+	r := func(wi, sign float64) float64 {
+		b := (w1*w1 - w0*w0 + sign*rho2*rho2*u1*u1) / (2 * wi * rho2 * u1)
+		return math.Log(math.Sqrt(b*b+1) - b)
+	}
+	r0 := r(w0, 1)
+	s := t * (r(w1, -1) - r0) / flyRho
+	w = w0 * math.Cosh(r0) / math.Cosh(flyRho*s+r0)
+	u = w0 * (math.Cosh(r0)*math.Tanh(flyRho*s+r0) - math.Sinh(r0)) / rho2
+	return u, w
+}
+
+// setView centres the map on a location at a zoom level.
+func (m *Map) setView(lat, lon, level float64) {
+	level = math.Max(0, math.Min(maxZoom, level))
+	m.zoom = int(math.Round(level))
+	m.zoomScale = float32(math.Exp2(level - float64(m.zoom)))
+	m.PanToLatLon(lat, lon)
+}
+
+// pinch returns the middle of and distance between the two touches on the map.
+func (m *Map) pinch() (fyne.Position, float32) {
+	var points []fyne.Position
+	for _, p := range m.touches {
+		points = append(points, p)
+	}
+	mid := fyne.NewPos((points[0].X+points[1].X)/2, (points[0].Y+points[1].Y)/2)
+	return mid, float32(math.Hypot(float64(points[0].X-points[1].X), float64(points[0].Y-points[1].Y)))
+}
+
+func (m *Map) setZoomLevel(level float64) {
+	size := m.Size()
+	m.setZoomLevelAt(level, fyne.NewPos(size.Width/2, size.Height/2))
+}
+
+// setZoomLevelAt changes the zoom level while keeping the location at a position in the same place.
+// The tiles are taken from the nearest whole zoom level and scaled to fit.
+func (m *Map) setZoomLevelAt(level float64, pos fyne.Position) {
+	level = math.Max(0, math.Min(maxZoom, level))
+	if m.Size().IsZero() {
+		m.zoom = int(math.Round(level))
+		m.zoomScale = float32(math.Exp2(level - float64(m.zoom)))
+		return
+	}
+
+	lat, lon := m.LatLonAt(pos)
+	size := m.Size()
+	centre := fyne.NewPos(size.Width/2, size.Height/2)
+	if pos == centre { // avoid rounding errors in the common case
+		lat, lon = m.getCenterLatLon()
+	}
+
+	m.zoom = int(math.Round(level))
+	m.zoomScale = float32(math.Exp2(level - float64(m.zoom)))
+	m.PanToLatLon(lat, lon)
+	m.panBy(pos.X-centre.X, pos.Y-centre.Y)
+}
+
+func (m *Map) stopZoomAnimation() {
+	if m.zoomAnim == nil {
+		return
+	}
+	m.zoomAnim.Stop()
+	m.zoomAnim = nil
+}
+
+// zoomInLevel returns the next whole zoom level above the current level, if it is not above the maximum
+func (m *Map) zoomInLevel() (float64, bool) {
+	level := math.Floor(m.ZoomLevel()+0.01) + 1
+	return level, level <= maxZoom
+}
+
+// zoomOutLevel returns the next whole zoom level below the current level, if it is not below 0
+func (m *Map) zoomOutLevel() (float64, bool) {
+	level := math.Ceil(m.ZoomLevel()-0.01) - 1
+	return level, level >= 0
 }
 
 func (m *Map) zoomInStep() {
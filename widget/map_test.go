@@ -2,8 +2,10 @@ package widget
 
 import (
 	"testing"
+	"time"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/mobile"
 	"fyne.io/fyne/v2/test"
 
 	"github.com/stretchr/testify/assert"
@@ -119,3 +121,250 @@ func TestNewMap_GetPosFromLatLon(t *testing.T) {
 	assert.InDelta(t, float32(256), pos.X, 10.0)
 	assert.InDelta(t, float32(161), pos.Y, 10.0)
 }
+
+func TestMap_SetZoomLevel(t *testing.T) {
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999))
+	m.Resize(fyne.NewSize(512, 512))
+	m.Zoom(9)
+
+	m.SetZoomLevel(9.5)
+	assert.InDelta(t, 9.5, m.ZoomLevel(), 0.001)
+	assert.Equal(t, 10, m.zoom) // the nearest tiles are used
+	lat, lon := m.getCenterLatLon()
+	assert.InDelta(t, 55.9486, lat, 0.001)
+	assert.InDelta(t, -3.1999, lon, 0.001)
+
+	m.SetZoomLevel(25)
+	assert.Equal(t, float64(maxZoom), m.ZoomLevel())
+}
+
+func TestMap_Scrolled(t *testing.T) {
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999))
+	m.Resize(fyne.NewSize(512, 512))
+	m.Zoom(9)
+
+	pointer := fyne.NewPos(100, 150)
+	lat, lon := m.LatLonAt(pointer)
+	m.Scrolled(&fyne.ScrollEvent{PointEvent: fyne.PointEvent{Position: pointer}, Scrolled: fyne.Delta{DY: 10}})
+	assert.InDelta(t, 9.25, m.ZoomLevel(), 0.001)
+
+	// the location under the pointer stays in place
+	pos := m.getPosFromLatLon(lat, lon)
+	assert.InDelta(t, pointer.X, pos.X, 0.5)
+	assert.InDelta(t, pointer.Y, pos.Y, 0.5)
+
+	m.Scrolled(&fyne.ScrollEvent{PointEvent: fyne.PointEvent{Position: pointer}, Scrolled: fyne.Delta{DY: -30}})
+	assert.InDelta(t, 8.5, m.ZoomLevel(), 0.001)
+	pos = m.getPosFromLatLon(lat, lon)
+	assert.InDelta(t, pointer.X, pos.X, 0.5)
+	assert.InDelta(t, pointer.Y, pos.Y, 0.5)
+}
+
+func TestMap_Pinch(t *testing.T) {
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999))
+	m.Resize(fyne.NewSize(512, 512))
+	m.Zoom(9)
+	lat, lon := m.LatLonAt(fyne.NewPos(250, 200))
+
+	touch := func(id int, x, y float32) *mobile.TouchEvent {
+		return &mobile.TouchEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(x, y)}, ID: id}
+	}
+	m.TouchDown(touch(0, 200, 200))
+	m.TouchDown(touch(1, 300, 200))
+	m.TouchMoved(touch(1, 400, 200)) // double the distance
+	assert.InDelta(t, 10, m.ZoomLevel(), 0.001)
+
+	// the location between the fingers follows them
+	pos := m.getPosFromLatLon(lat, lon)
+	assert.InDelta(t, 300, pos.X, 0.5)
+	assert.InDelta(t, 200, pos.Y, 0.5)
+
+	m.Dragged(&fyne.DragEvent{Dragged: fyne.Delta{DX: 50}}) // ignored while pinching
+	pos = m.getPosFromLatLon(lat, lon)
+	assert.InDelta(t, 300, pos.X, 0.5)
+
+	m.TouchUp(touch(1, 400, 200))
+	m.TouchUp(touch(0, 200, 200))
+	assert.Empty(t, m.touches)
+}
+
+func TestMap_ZoomInAnimated(t *testing.T) {
+	w := test.NewTempWindow(t, nil)
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999))
+	w.SetContent(m)
+	w.Resize(fyne.NewSize(512, 512))
+	m.Zoom(9)
+
+	m.SetZoomLevel(9.3)
+	m.ZoomInAnimated() // the test driver completes animations immediately
+	assert.Equal(t, 10.0, m.ZoomLevel())
+	assert.Equal(t, float32(1), m.zoomScale)
+	m.ZoomOutAnimated()
+	assert.Equal(t, 9.0, m.ZoomLevel())
+
+	m.SetZoomLevel(9.3)
+	m.ZoomIn()
+	assert.Nil(t, m.zoomAnim)
+	assert.Equal(t, 10.0, m.ZoomLevel())
+}
+
+func TestMap_SetZoomLevelBeforeResize(t *testing.T) {
+	m := NewMap()
+	m.SetZoomLevel(9.5)
+	assert.InDelta(t, 9.5, m.ZoomLevel(), 1e-6)
+	m.ZoomOut()
+	assert.Equal(t, 9.0, m.ZoomLevel())
+}
+
+func TestMap_FlyTo(t *testing.T) {
+	w := test.NewTempWindow(t, nil)
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999))
+	w.SetContent(m)
+	w.Resize(fyne.NewSize(512, 512))
+	m.Zoom(9)
+
+	m.FlyTo(51.5072, -0.1276, 12.5, time.Second) // the test driver completes animations immediately
+	assert.InDelta(t, 12.5, m.ZoomLevel(), 0.001)
+	lat, lon := m.LatLonAt(fyne.NewPos(m.Size().Width/2, m.Size().Height/2))
+	assert.InDelta(t, 51.5072, lat, 0.0001)
+	assert.InDelta(t, -0.1276, lon, 0.0001)
+
+	assert.NotNil(t, m.zoomAnim)
+	m.Dragged(&fyne.DragEvent{Dragged: fyne.NewDelta(10, 0)})
+	assert.Nil(t, m.zoomAnim)
+}
+
+func TestFlyPath(t *testing.T) {
+	u, w := flyPath(512, 64, 2000, 0)
+	assert.InDelta(t, 0, u, 1e-9)
+	assert.InDelta(t, 512, w, 1e-9)
+	u, w = flyPath(512, 64, 2000, 1)
+	assert.InDelta(t, 2000, u, 1e-6)
+	assert.InDelta(t, 64, w, 1e-6)
+
+	// travelling far zooms out to show more than at either end
+	u, w = flyPath(512, 64, 20000, 0.5)
+	assert.Greater(t, w, 512.0)
+	assert.Greater(t, u, 0.0)
+	assert.Less(t, u, 20000.0)
+
+	// with no distance to travel the zoom changes evenly
+	u, w = flyPath(512, 128, 0, 0.5)
+	assert.Zero(t, u)
+	assert.InDelta(t, 256, w, 1e-9)
+}
+
+func TestMap_LatLonAt(t *testing.T) {
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999))
+	m.Resize(fyne.NewSize(520, 328))
+	m.Zoom(9)
+
+	lat, lon := m.LatLonAt(fyne.NewPos(260, 164))
+	assert.InDelta(t, 55.9486, lat, 0.001)
+	assert.InDelta(t, -3.1999, lon, 0.001)
+
+	lat, lon = m.LatLonAt(fyne.NewPos(10, 300))
+	pos := m.PositionOf(lat, lon)
+	assert.InDelta(t, 10, pos.X, 0.01)
+	assert.InDelta(t, 300, pos.Y, 0.01)
+}
+
+func TestMap_OnTapped(t *testing.T) {
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999))
+	m.Resize(fyne.NewSize(520, 328))
+	m.Zoom(9)
+
+	var lat, lon float64
+	m.OnTapped = func(la, lo float64) {
+		lat, lon = la, lo
+	}
+	test.TapAt(m, fyne.NewPos(260, 164))
+	assert.InDelta(t, 55.9486, lat, 0.001)
+	assert.InDelta(t, -3.1999, lon, 0.001)
+}
+
+func TestMap_OnViewportChanged(t *testing.T) {
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999))
+	m.Resize(fyne.NewSize(520, 328))
+	m.Zoom(9)
+
+	changes := 0
+	var bounds MapBounds
+	var zoom float64
+	m.OnViewportChanged = func(b MapBounds, z float64) {
+		changes++
+		bounds, zoom = b, z
+	}
+	m.ZoomIn()
+	assert.Equal(t, 1, changes)
+	assert.Equal(t, 10.0, zoom)
+	assert.Less(t, bounds.MinLat, 55.9486)
+	assert.Greater(t, bounds.MaxLat, 55.9486)
+	assert.Less(t, bounds.MinLon, -3.1999)
+	assert.Greater(t, bounds.MaxLon, -3.1999)
+
+	m.Refresh() // no change
+	assert.Equal(t, 1, changes)
+
+	m.Dragged(&fyne.DragEvent{Dragged: fyne.Delta{DX: 10}})
+	assert.Equal(t, 2, changes)
+	assert.Equal(t, 10.0, zoom)
+
+	m.Resize(fyne.NewSize(600, 400))
+	assert.Equal(t, 3, changes)
+}
+
+func TestMap_OnMarkerTapped(t *testing.T) {
+	test.NewTempApp(t)
+	marker := NewMapMarker(55.9486, -3.1999, "Edinburgh")
+	m := NewMapWithOptions(WithMapMarkers([]MapMarker{marker}))
+
+	var tapped MapMarker
+	m.OnMarkerTapped = func(mm MapMarker) {
+		tapped = mm
+	}
+	m.markerObjs[0].setup()
+	test.Tap(m.markerObjs[0].item)
+	assert.Equal(t, marker, tapped)
+	assert.True(t, m.markerObjs[0].title.Visible())
+}
+
+func TestMap_FitBounds(t *testing.T) {
+	m := NewMap()
+	m.FitBounds(55.85, -3.35, 56.0, -3.05, 10) // applied once the map has a size
+	m.Resize(fyne.NewSize(520, 328))
+
+	assert.Equal(t, 10.0, m.ZoomLevel())
+	bounds := m.VisibleBounds()
+	assert.Less(t, bounds.MinLat, 55.85)
+	assert.Greater(t, bounds.MaxLat, 56.0)
+	assert.Less(t, bounds.MinLon, -3.35)
+	assert.Greater(t, bounds.MaxLon, -3.05)
+	lat, lon := m.getCenterLatLon()
+	assert.InDelta(t, 55.925, lat, 0.01)
+	assert.InDelta(t, -3.2, lon, 0.001)
+
+	m.FitBounds(51.3, -0.5, 51.7, 0.3, 10)
+	assert.Equal(t, 9.0, m.ZoomLevel())
+	lat, lon = m.getCenterLatLon()
+	assert.InDelta(t, 51.5, lat, 0.01)
+	assert.InDelta(t, -0.1, lon, 0.001)
+}
+
+func TestMap_VisibleBounds(t *testing.T) {
+	m := NewMapWithOptions(AtLatLon(0, 0))
+	m.Resize(fyne.NewSize(1024, 1024))
+
+	// at zoom 0 the whole world fits with space either side
+	bounds := m.VisibleBounds()
+	assert.Equal(t, -180.0, bounds.MinLon)
+	assert.Equal(t, 180.0, bounds.MaxLon)
+	assert.Equal(t, maxLatitude, bounds.MaxLat)
+
+	m.Zoom(2)
+	bounds = m.VisibleBounds()
+	assert.InDelta(t, -180, bounds.MinLon, 0.001)
+	assert.InDelta(t, 180, bounds.MaxLon, 0.001)
+	assert.InDelta(t, -85.05, bounds.MinLat, 0.01)
+	assert.InDelta(t, 85.05, bounds.MaxLat, 0.01)
+}
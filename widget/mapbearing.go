@@ -0,0 +1,203 @@
+package widget
+
+import (
+	"image"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+// AtBearing configures the map to start rotated so that a direction, in degrees clockwise from north, is at the top.
+func AtBearing(degrees float64) MapOption {
+	return func(m *Map) {
+		m.bearing = normalizeBearing(degrees)
+	}
+}
+
+// WithCompass enables or disables a compass above the zoom buttons which shows the direction of north.
+// Tapping the compass rotates the map back to north up.
+func WithCompass(enable bool) MapOption {
+	return func(m *Map) {
+		m.showCompass = enable
+	}
+}
+
+// Bearing returns the direction at the top of the map, in degrees clockwise from north.
+func (m *Map) Bearing() float64 {
+	return m.bearing
+}
+
+// SetBearing rotates the map around its middle so that a direction, in degrees clockwise from north, is at the top.
+// For example a bearing of 90 shows east at the top, as a navigation display would when travelling east.
+// Markers stay upright and move with the locations that they mark.
+func (m *Map) SetBearing(degrees float64) {
+	degrees = normalizeBearing(degrees)
+	if degrees == m.bearing {
+		return
+	}
+
+	m.bearing = degrees
+	if m.overlayRaster != nil {
+		m.overlayRaster.Refresh()
+	}
+	m.Refresh()
+}
+
+func (m *Map) resetBearing() {
+	m.SetBearing(0)
+}
+
+// toScreen rotates a position of the north up map to where it is shown at the current bearing.
+func (m *Map) toScreen(pos fyne.Position) fyne.Position {
+	if m.bearing == 0 {
+		return pos
+	}
+
+	size := m.Size()
+	x, y := rotateBy(pos.X-size.Width/2, pos.Y-size.Height/2, -m.bearing)
+	return fyne.NewPos(size.Width/2+x, size.Height/2+y)
+}
+
+// fromScreen returns the position of the north up map that is shown at a position at the current bearing.
+func (m *Map) fromScreen(pos fyne.Position) fyne.Position {
+	if m.bearing == 0 {
+		return pos
+	}
+
+	size := m.Size()
+	x, y := rotateBy(pos.X-size.Width/2, pos.Y-size.Height/2, m.bearing)
+	return fyne.NewPos(size.Width/2+x, size.Height/2+y)
+}
+
+// rotatedSize returns the width and height of the screen area that a north up area covers at the current bearing.
+func (m *Map) rotatedSize(w, h float64) (float64, float64) {
+	sin, cos := math.Sincos(m.bearing * math.Pi / 180)
+	sin, cos = math.Abs(sin), math.Abs(cos)
+	return w*cos + h*sin, w*sin + h*cos
+}
+
+// drawRotated draws the tiles north up into an image large enough to cover the map at any angle,
+// then rotates that into the map pixels.
+func (m *Map) drawRotated(scale float32) {
+	side := int(math.Ceil(math.Hypot(float64(m.w), float64(m.h))))
+	if m.unrotated == nil || m.unrotated.Bounds().Dx() != side {
+		m.unrotated = image.NewNRGBA(image.Rect(0, 0, side, side))
+	} else {
+		draw.Draw(m.unrotated, m.unrotated.Bounds(), image.Transparent, image.Point{}, draw.Src)
+	}
+	m.drawTiles(m.unrotated, scale)
+
+	sin, cos := math.Sincos(m.bearing * math.Pi / 180)
+	mid := float64(side) / 2
+	midX, midY := float64(m.w)/2, float64(m.h)/2
+	rotate := f64.Aff3{
+		cos, sin, midX - cos*mid - sin*mid,
+		-sin, cos, midY + sin*mid - cos*mid,
+	}
+	draw.ApproxBiLinear.Transform(m.pixels, rotate, m.unrotated, m.unrotated.Bounds(), draw.Src, nil)
+}
+
+// rotateBy turns a vector clockwise on screen by an angle in degrees.
+func rotateBy(x, y float32, degrees float64) (float32, float32) {
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	return float32(float64(x)*cos - float64(y)*sin), float32(float64(x)*sin + float64(y)*cos)
+}
+
+// normalizeBearing returns the same direction as an angle in degrees, from 0 up to 360.
+func normalizeBearing(degrees float64) float64 {
+	degrees = math.Mod(degrees, 360)
+	if degrees < 0 {
+		degrees += 360
+	}
+	return degrees
+}
+
+// mapCompass is a button with a needle whose coloured end points north,
+// tapping it rotates the map back to north up.
+type mapCompass struct {
+	widget.BaseWidget
+
+	bearing  float64
+	onTapped func()
+}
+
+func newMapCompass(tapped func()) *mapCompass {
+	c := &mapCompass{onTapped: tapped}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+func (c *mapCompass) CreateRenderer() fyne.WidgetRenderer {
+	r := &mapCompassRenderer{c: c, bg: canvas.NewRectangle(nil), north: canvas.NewLine(nil), south: canvas.NewLine(nil)}
+	r.Refresh()
+	return r
+}
+
+// MinSize returns the size of a map button, so that the compass lines up with the zoom buttons.
+func (c *mapCompass) MinSize() fyne.Size {
+	return fyne.NewSquareSize(theme.IconInlineSize() + theme.InnerPadding()*2)
+}
+
+// Tapped rotates the map to north up.
+func (c *mapCompass) Tapped(*fyne.PointEvent) {
+	if c.onTapped != nil {
+		c.onTapped()
+	}
+}
+
+func (c *mapCompass) setBearing(bearing float64) {
+	if bearing == c.bearing {
+		return
+	}
+
+	c.bearing = bearing
+	c.Refresh()
+}
+
+type mapCompassRenderer struct {
+	c *mapCompass
+
+	bg           *canvas.Rectangle
+	north, south *canvas.Line
+}
+
+func (r *mapCompassRenderer) Destroy() {
+}
+
+func (r *mapCompassRenderer) Layout(s fyne.Size) {
+	halfPad := theme.Padding() / 2
+	r.bg.Move(fyne.NewPos(halfPad, halfPad))
+	r.bg.Resize(s.Subtract(fyne.NewSize(theme.Padding(), theme.Padding())))
+
+	mid := fyne.NewPos(s.Width/2, s.Height/2)
+	length := theme.IconInlineSize() / 2
+	x, y := rotateBy(0, -length, -r.c.bearing)
+	r.north.Position1, r.north.Position2 = mid, mid.AddXY(x, y)
+	r.south.Position1, r.south.Position2 = mid, mid.AddXY(-x, -y)
+}
+
+func (r *mapCompassRenderer) MinSize() fyne.Size {
+	return r.c.MinSize()
+}
+
+func (r *mapCompassRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.bg, r.south, r.north}
+}
+
+func (r *mapCompassRenderer) Refresh() {
+	r.bg.FillColor = theme.ColorForWidget(theme.ColorNameShadow, r.c)
+	r.north.StrokeColor = theme.ColorForWidget(theme.ColorNameError, r.c)
+	r.south.StrokeColor = theme.ColorForWidget(theme.ColorNameForeground, r.c)
+	r.north.StrokeWidth, r.south.StrokeWidth = 3, 3
+	r.Layout(r.c.Size())
+
+	r.bg.Refresh()
+	r.north.Refresh()
+	r.south.Refresh()
+}
@@ -0,0 +1,130 @@
+package widget
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testHalvesTileProvider returns red tiles for the west of the world and blue tiles for the east.
+type testHalvesTileProvider struct{}
+
+func (p *testHalvesTileProvider) Tile(_ context.Context, zoom, x, _ int) (image.Image, error) {
+	if x < 1<<zoom/2 {
+		return testTileImage(tileSize, color.NRGBA{R: 0xff, A: 0xff}), nil
+	}
+	return testTileImage(tileSize, color.NRGBA{B: 0xff, A: 0xff}), nil
+}
+
+func TestMap_SetBearing(t *testing.T) {
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999))
+	m.Resize(fyne.NewSize(520, 328))
+	m.Zoom(9)
+	centre := m.PositionOf(55.9486, -3.1999)
+
+	m.SetBearing(-90)
+	assert.Equal(t, float64(270), m.Bearing())
+	m.SetBearing(450)
+	assert.Equal(t, float64(90), m.Bearing())
+
+	// the middle stays in place, and east is now at the top
+	pos := m.PositionOf(55.9486, -3.1999)
+	assert.InDelta(t, centre.X, pos.X, 0.01)
+	assert.InDelta(t, centre.Y, pos.Y, 0.01)
+	pos = m.PositionOf(55.9486, -3.0)
+	assert.InDelta(t, centre.X, pos.X, 0.5)
+	assert.InDelta(t, centre.Y-72.5, pos.Y, 1)
+
+	lat, lon := m.LatLonAt(fyne.NewPos(10, 300))
+	pos = m.PositionOf(lat, lon)
+	assert.InDelta(t, 10, pos.X, 0.01)
+	assert.InDelta(t, 300, pos.Y, 0.01)
+}
+
+func TestMap_BearingDrag(t *testing.T) {
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999), AtBearing(135))
+	m.Resize(fyne.NewSize(200, 200))
+	m.Zoom(9)
+
+	before := m.PositionOf(55.9486, -3.1999)
+	m.Dragged(&fyne.DragEvent{Dragged: fyne.Delta{DX: 30, DY: -20}})
+	after := m.PositionOf(55.9486, -3.1999)
+	assert.InDelta(t, before.X+30, after.X, 0.01)
+	assert.InDelta(t, before.Y-20, after.Y, 0.01)
+}
+
+func TestMap_BearingVisibleBounds(t *testing.T) {
+	m := NewMapWithOptions(AtLatLon(0, 0))
+	m.Resize(fyne.NewSize(256, 128))
+	m.Zoom(3)
+	flat := m.VisibleBounds()
+
+	m.SetBearing(90)
+	turned := m.VisibleBounds()
+	assert.InDelta(t, flat.MaxLon-flat.MinLon, (turned.MaxLon-turned.MinLon)*2, 0.001)
+	assert.Greater(t, turned.MaxLat-turned.MinLat, flat.MaxLat-flat.MinLat)
+}
+
+func TestMap_DrawRotated(t *testing.T) {
+	test.NewTempApp(t)
+	base := NewMapTileLayer(&testHalvesTileProvider{}, "", "")
+	m := NewMapWithOptions(WithTileLayers(base))
+	m.Resize(fyne.NewSize(256, 256))
+	m.Zoom(1)
+
+	m.draw(256, 256)
+	for x := 0; x < 2; x++ {
+		for y := 0; y < 2; y++ {
+			waitForTile(t, base.loader, MapTileKey{Zoom: 1, X: x, Y: y})
+		}
+	}
+	img := m.draw(256, 256)
+	assert.Equal(t, color.NRGBA{R: 0xff, A: 0xff}, img.At(40, 128))
+	assert.Equal(t, color.NRGBA{B: 0xff, A: 0xff}, img.At(216, 128))
+
+	m.SetBearing(90)
+	img = m.draw(256, 256)
+	assert.Equal(t, color.NRGBA{B: 0xff, A: 0xff}, img.At(128, 40))
+	assert.Equal(t, color.NRGBA{R: 0xff, A: 0xff}, img.At(128, 216))
+	assert.Equal(t, color.NRGBA{B: 0xff, A: 0xff}, img.At(2, 2)) // the corners are filled
+}
+
+func TestMap_BearingMarkers(t *testing.T) {
+	test.NewTempApp(t)
+	marker := &CustomMapMarker{Latitude: 55.9486, Longitude: -3.0}
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999), WithMapMarkers([]MapMarker{marker}))
+	w := test.NewTempWindow(t, m)
+	w.Resize(fyne.NewSize(400, 400))
+	m.Zoom(9)
+
+	m.SetBearing(90)
+	m.markers.Layout.Layout(m.markers.Objects, m.Size())
+	item := m.markerObjs[0]
+	pos := m.PositionOf(marker.Lat(), marker.Lon())
+	off := item.pinOffset()
+	assert.Equal(t, pos.SubtractXY(off.X, off.Y), item.Position())
+	assert.Less(t, pos.Y, m.Size().Height/2)
+}
+
+func TestMap_Compass(t *testing.T) {
+	test.NewTempApp(t)
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999), WithCompass(true), AtBearing(45))
+	w := test.NewTempWindow(t, m)
+	w.Resize(fyne.NewSize(400, 400))
+
+	assert.NotNil(t, m.compass)
+	assert.Equal(t, float64(45), m.compass.bearing)
+
+	m.SetBearing(200)
+	assert.Equal(t, float64(200), m.compass.bearing)
+
+	test.Tap(m.compass)
+	assert.Zero(t, m.Bearing())
+	assert.Zero(t, m.compass.bearing)
+}
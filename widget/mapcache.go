@@ -1,40 +1,255 @@
 package widget
 
 import (
+	"bytes"
+	"container/list"
+	"context"
 	"errors"
 	"fmt"
 	"image"
+	"image/jpeg"
 	"image/png"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/image/webp"
+)
+
+const (
+	// defaultTileCacheSize is the number of tiles kept in memory by default, around 50MB of decoded images.
+	defaultTileCacheSize = 200
+	// defaultTileExpiry is used when a tile server sends no caching headers, as per the OSM tile usage policy.
+	defaultTileExpiry = 7 * 24 * time.Hour
 )
 
-var tileMap = make(map[string]image.Image)
+// MapTileKey identifies a single tile from a tile source.
+type MapTileKey struct {
+	Source     string // the tile source URL template
+	Zoom, X, Y int
+}
+
+// MapTile is a downloaded tile along with the HTTP metadata needed to revalidate it.
+type MapTile struct {
+	Data    []byte    // the encoded image as returned by the tile server
+	ETag    string    // the entity tag returned by the tile server, if any
+	Expires time.Time // when the tile should be revalidated, the zero time means never
+
+	img  image.Image // decoded image, kept so that in-memory caches do not decode again
+	lock sync.Mutex  // guards img, as a cached tile is shared by every goroutine that gets it
+}
+
+// Expired returns true if this tile should be checked with the tile server before being used.
+func (t *MapTile) Expired() bool {
+	return !t.Expires.IsZero() && time.Now().After(t.Expires)
+}
+
+// image returns the decoded image of the tile, decoding it the first time it is needed.
+func (t *MapTile) image() (image.Image, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.img != nil {
+		return t.img, nil
+	}
+
+	img, err := decodeTile(t.Data)
+	if err != nil {
+		return nil, err
+	}
+	t.img = img
+	return img, nil
+}
+
+// decoded returns the image of the tile if it has already been decoded.
+func (t *MapTile) decoded() image.Image {
+	t.lock.Lock()
+	defer t.lock.Unlock()
 
-func getTile(tileSource string, x, y, zoom int, cl *http.Client) (image.Image, error) {
+	return t.img
+}
+
+// decodeTile reads a PNG, JPEG or WebP tile image, detecting the format from the data
+// as tile servers do not always send an accurate content type.
+func decodeTile(data []byte) (image.Image, error) {
+	switch format := http.DetectContentType(data); format {
+	case "image/png":
+		return png.Decode(bytes.NewReader(data))
+	case "image/jpeg":
+		return jpeg.Decode(bytes.NewReader(data))
+	case "image/webp":
+		return webp.Decode(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("unsupported tile image type %q", format)
+	}
+}
+
+// MapTileCache stores map tiles so that they do not need to be downloaded again.
+// Implementations must be safe for concurrent use.
+type MapTileCache interface {
+	// Get returns the tile stored for the key, if there is one.
+	Get(key MapTileKey) (*MapTile, bool)
+	// Put stores a tile, replacing any previous tile for the same key.
+	Put(key MapTileKey, tile *MapTile)
+}
+
+// MemoryTileCache is a MapTileCache that holds a limited number of tiles in memory.
+// When it is full the least recently used tile is removed.
+type MemoryTileCache struct {
+	max   int
+	items map[MapTileKey]*list.Element
+	order *list.List
+	lock  sync.Mutex
+}
+
+type memoryTileEntry struct {
+	key  MapTileKey
+	tile *MapTile
+}
+
+// NewMemoryTileCache creates a new in-memory tile cache that will hold up to maxTiles tiles.
+func NewMemoryTileCache(maxTiles int) *MemoryTileCache {
+	if maxTiles < 1 {
+		maxTiles = 1
+	}
+	return &MemoryTileCache{max: maxTiles, items: make(map[MapTileKey]*list.Element), order: list.New()}
+}
+
+// Get returns the tile stored for the key, if there is one, and marks it as recently used.
+func (c *MemoryTileCache) Get(key MapTileKey) (*MapTile, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	item, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(item)
+	return item.Value.(*memoryTileEntry).tile, true
+}
+
+// Len returns the number of tiles currently in the cache.
+func (c *MemoryTileCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.order.Len()
+}
+
+// grow increases the number of tiles that the cache can hold, if it is less than maxTiles.
+func (c *MemoryTileCache) grow(maxTiles int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if maxTiles > c.max {
+		c.max = maxTiles
+	}
+}
+
+// Put stores a tile, removing the least recently used tile if the cache is full.
+func (c *MemoryTileCache) Put(key MapTileKey, tile *MapTile) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if item, ok := c.items[key]; ok {
+		item.Value.(*memoryTileEntry).tile = tile
+		c.order.MoveToFront(item)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&memoryTileEntry{key: key, tile: tile})
+	for c.order.Len() > c.max {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.items, last.Value.(*memoryTileEntry).key)
+	}
+}
+
+func getTile(ctx context.Context, tileSource string, x, y, zoom int, cl *http.Client, cache MapTileCache) (image.Image, error) {
 	if tileSource == "" {
 		return nil, errors.New("no tileSource provided")
 	}
 
-	u := fmt.Sprintf(tileSource, zoom, x, y)
-	if tile, ok := tileMap[u]; ok {
-		return tile, nil
+	key := MapTileKey{Source: tileSource, Zoom: zoom, X: x, Y: y}
+	cached, ok := cache.Get(key)
+	if ok && !cached.Expired() {
+		return cached.image()
+	}
+
+	tile, err := fetchTile(ctx, tileURL(tileSource, zoom, x, y), cached, cl)
+	if err != nil {
+		if ok { // a stale tile is better than none when the network is unavailable
+			return cached.image()
+		}
+		return nil, err
+	}
+
+	img, err := tile.image()
+	if err != nil {
+		return nil, err
 	}
+	cache.Put(key, tile)
+	return img, nil
+}
 
-	req, err := http.NewRequest("GET", u, nil)
+// fetchTile downloads the tile at the URL u.
+// If a previous copy of the tile is provided it will be revalidated using its ETag.
+func fetchTile(ctx context.Context, u string, previous *MapTile, cl *http.Client) (*MapTile, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("User-Agent", "Fyne-X Map Widget/0.1")
+	if previous != nil && previous.ETag != "" {
+		req.Header.Set("If-None-Match", previous.ETag)
+	}
 	res, err := cl.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer res.Body.Close()
 
-	img, err := png.Decode(res.Body)
-	if err == nil {
-		tileMap[u] = img
+	if res.StatusCode == http.StatusNotModified && previous != nil {
+		return &MapTile{Data: previous.Data, ETag: previous.ETag, Expires: tileExpiry(res.Header),
+			img: previous.decoded()}, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tile request failed: %s", res.Status)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &MapTile{Data: data, ETag: res.Header.Get("ETag"), Expires: tileExpiry(res.Header)}, nil
+}
+
+// tileExpiry works out when a tile should be revalidated from the Cache-Control and Expires headers.
+func tileExpiry(h http.Header) time.Time {
+	now := time.Now()
+	if cc := h.Get("Cache-Control"); cc != "" {
+		for _, directive := range strings.Split(cc, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			switch strings.ToLower(name) {
+			case "no-cache", "no-store":
+				return now
+			case "max-age":
+				if secs, err := strconv.Atoi(value); err == nil {
+					return now.Add(time.Duration(secs) * time.Second)
+				}
+			}
+		}
+	}
+
+	if exp := h.Get("Expires"); exp != "" {
+		if t, err := http.ParseTime(exp); err == nil {
+			return t
+		}
+		return now // invalid dates mean already expired
 	}
-	return img, err
+	return now.Add(defaultTileExpiry)
 }
@@ -0,0 +1,146 @@
+package widget
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testTileData(t *testing.T) []byte {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, tileSize, tileSize)))
+	assert.Nil(t, err)
+	return buf.Bytes()
+}
+
+func TestMemoryTileCache_Evict(t *testing.T) {
+	c := NewMemoryTileCache(2)
+	c.Put(MapTileKey{Zoom: 1}, &MapTile{})
+	c.Put(MapTileKey{Zoom: 2}, &MapTile{})
+	_, ok := c.Get(MapTileKey{Zoom: 1})
+	assert.True(t, ok)
+
+	c.Put(MapTileKey{Zoom: 3}, &MapTile{})
+	assert.Equal(t, 2, c.Len())
+	_, ok = c.Get(MapTileKey{Zoom: 1})
+	assert.True(t, ok)
+	_, ok = c.Get(MapTileKey{Zoom: 2})
+	assert.False(t, ok)
+}
+
+func TestGetTile_Revalidate(t *testing.T) {
+	data := testTileData(t)
+	requests, notModified := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"abc"` {
+			notModified++
+			w.Header().Set("Cache-Control", "max-age=0")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"abc"`)
+		w.Header().Set("Cache-Control", "public, max-age=0")
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	cache := NewMemoryTileCache(10)
+	source := server.URL + "/%d/%d/%d.png"
+	img, err := getTile(context.Background(), source, 1, 2, 3, server.Client(), cache)
+	assert.Nil(t, err)
+	assert.NotNil(t, img)
+	assert.Equal(t, 1, requests)
+
+	time.Sleep(time.Millisecond)
+	img2, err := getTile(context.Background(), source, 1, 2, 3, server.Client(), cache)
+	assert.Nil(t, err)
+	assert.Equal(t, img, img2)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, 1, notModified)
+}
+
+func TestGetTile_Cached(t *testing.T) {
+	data := testTileData(t)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Cache-Control", "max-age=3600")
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	cache := NewMemoryTileCache(10)
+	source := server.URL + "/%d/%d/%d.png"
+	_, err := getTile(context.Background(), source, 1, 2, 3, server.Client(), cache)
+	assert.Nil(t, err)
+	_, err = getTile(context.Background(), source, 1, 2, 3, server.Client(), cache)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, requests)
+
+	tile, ok := cache.Get(MapTileKey{Source: source, Zoom: 3, X: 1, Y: 2})
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), tile.Expires, time.Minute)
+}
+
+func TestDiskTileCache(t *testing.T) {
+	dir := t.TempDir()
+	data := testTileData(t)
+	key := MapTileKey{Source: "https://example.com/%d/%d/%d.png", Zoom: 4, X: 5, Y: 6}
+	expires := time.Now().Add(time.Hour).Round(time.Second)
+
+	c := NewDiskTileCache(dir)
+	c.Put(key, &MapTile{Data: data, ETag: "tag", Expires: expires})
+
+	c = NewDiskTileCache(dir) // simulate a restart
+	tile, ok := c.Get(key)
+	assert.True(t, ok)
+	assert.Equal(t, data, tile.Data)
+	assert.Equal(t, "tag", tile.ETag)
+	assert.True(t, expires.Equal(tile.Expires))
+
+	_, ok = c.Get(MapTileKey{Source: key.Source, Zoom: 4, X: 5, Y: 7})
+	assert.False(t, ok)
+}
+
+func TestDiskTileCache_Preload(t *testing.T) {
+	data := testTileData(t)
+	source := "https://example.com/%d/%d/%d.png"
+	c := NewDiskTileCache(t.TempDir())
+	assert.Nil(t, c.Preload(source, 1, 0, 1, data))
+
+	img, err := getTile(context.Background(), source, 0, 1, 1, nil, c) // no client, so any network access would fail
+	assert.Nil(t, err)
+	assert.Equal(t, tileSize, img.Bounds().Dx())
+}
+
+func TestDecodeTile(t *testing.T) {
+	img, err := decodeTile(testTileData(t))
+	assert.Nil(t, err)
+	assert.Equal(t, tileSize, img.Bounds().Dx())
+
+	var buf bytes.Buffer
+	err = jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, tileSize, tileSize)), nil)
+	assert.Nil(t, err)
+	img, err = decodeTile(buf.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, tileSize, img.Bounds().Dx())
+
+	data, err := os.ReadFile("testdata/map/tile.webp")
+	assert.Nil(t, err)
+	img, err = decodeTile(data)
+	assert.Nil(t, err)
+	assert.False(t, img.Bounds().Empty())
+
+	_, err = decodeTile([]byte("<html>Not found</html>"))
+	assert.EqualError(t, err, `unsupported tile image type "text/html; charset=utf-8"`)
+}
@@ -0,0 +1,101 @@
+package widget
+
+import (
+	"fmt"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// MapCoordinateFormat selects how the map coordinate readout writes a location.
+type MapCoordinateFormat int
+
+const (
+	// MapCoordinatesDecimal writes locations as signed decimal degrees, such as "55.94860, -3.19990".
+	MapCoordinatesDecimal MapCoordinateFormat = iota
+	// MapCoordinatesDMS writes locations as degrees, minutes and seconds, such as `55°56'55.0"N 3°11'59.6"W`.
+	MapCoordinatesDMS
+)
+
+// WithCoordinateReadout enables or disables a label showing the location under the mouse pointer.
+// When there is no pointer over the map the location of the middle of the map is shown.
+func WithCoordinateReadout(enable bool, format MapCoordinateFormat) MapOption {
+	return func(m *Map) {
+		m.showCoordinates = enable
+		m.coordinateFormat = format
+	}
+}
+
+// mapCoordinates is a label that shows a location in the chosen format.
+type mapCoordinates struct {
+	widget.BaseWidget
+
+	format   MapCoordinateFormat
+	lat, lon float64
+
+	bg   *canvas.Rectangle
+	text *canvas.Text
+}
+
+func newMapCoordinates(format MapCoordinateFormat) *mapCoordinates {
+	c := &mapCoordinates{format: format}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+// setLatLon updates the location shown.
+func (c *mapCoordinates) setLatLon(lat, lon float64) {
+	if lat == c.lat && lon == c.lon {
+		return
+	}
+
+	c.lat, c.lon = lat, lon
+	c.Refresh()
+}
+
+func (c *mapCoordinates) CreateRenderer() fyne.WidgetRenderer {
+	c.bg = canvas.NewRectangle(theme.ColorForWidget(theme.ColorNameShadow, c))
+	c.text = canvas.NewText("", theme.ColorForWidget(theme.ColorNameForeground, c))
+	c.text.TextSize = theme.CaptionTextSize()
+	c.text.TextStyle.Monospace = true // so the width does not change as the pointer moves
+	c.update()
+
+	return widget.NewSimpleRenderer(container.NewStack(c.bg, container.NewPadded(c.text)))
+}
+
+func (c *mapCoordinates) Refresh() {
+	if c.text != nil {
+		c.update()
+	}
+	c.BaseWidget.Refresh()
+}
+
+func (c *mapCoordinates) update() {
+	c.bg.FillColor = theme.ColorForWidget(theme.ColorNameShadow, c)
+	c.text.Color = theme.ColorForWidget(theme.ColorNameForeground, c)
+	c.text.Text = formatLatLon(c.lat, c.lon, c.format)
+}
+
+// formatLatLon writes a location in the coordinate format.
+func formatLatLon(lat, lon float64, format MapCoordinateFormat) string {
+	if format == MapCoordinatesDMS {
+		return formatDMS(lat, 'N', 'S') + " " + formatDMS(lon, 'E', 'W')
+	}
+	return fmt.Sprintf("%.5f, %.5f", lat, lon)
+}
+
+// formatDMS writes an angle as degrees, minutes and tenths of a second, followed by the hemisphere letter.
+func formatDMS(deg float64, positive, negative rune) string {
+	hemisphere := positive
+	if deg < 0 {
+		hemisphere = negative
+		deg = -deg
+	}
+
+	tenths := int(math.Round(deg * 36000)) // rounding first so that seconds never show as 60
+	return fmt.Sprintf("%d°%02d'%04.1f\"%c", tenths/36000, tenths/600%60, float64(tenths%600)/10, hemisphere)
+}
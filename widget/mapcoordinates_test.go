@@ -0,0 +1,42 @@
+package widget
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatLatLon(t *testing.T) {
+	assert.Equal(t, "55.94860, -3.19990", formatLatLon(55.9486, -3.1999, MapCoordinatesDecimal))
+	assert.Equal(t, `55°56'55.0"N 3°11'59.6"W`, formatLatLon(55.9486, -3.1999, MapCoordinatesDMS))
+	assert.Equal(t, `33°52'07.7"S 151°12'33.5"E`, formatLatLon(-33.8688, 151.2093, MapCoordinatesDMS))
+	assert.Equal(t, `1°00'00.0"N 0°00'00.0"E`, formatLatLon(0.999999, 0, MapCoordinatesDMS))
+}
+
+func TestMap_CoordinateReadout(t *testing.T) {
+	test.NewTempApp(t)
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999), WithCoordinateReadout(true, MapCoordinatesDecimal))
+	w := test.NewTempWindow(t, m)
+	w.Resize(fyne.NewSize(400, 400))
+	m.Zoom(9)
+
+	lat, lon := m.LatLonAt(fyne.NewPos(m.Size().Width/2, m.Size().Height/2))
+	assert.Equal(t, formatLatLon(lat, lon, MapCoordinatesDecimal), m.coordinates.text.Text)
+
+	pointer := fyne.NewPos(50, 80)
+	m.MouseMoved(&desktop.MouseEvent{PointEvent: fyne.PointEvent{Position: pointer}})
+	lat, lon = m.LatLonAt(pointer)
+	assert.Equal(t, formatLatLon(lat, lon, MapCoordinatesDecimal), m.coordinates.text.Text)
+
+	m.PanEast() // the location under a still pointer changes
+	lat, lon = m.LatLonAt(pointer)
+	assert.Equal(t, formatLatLon(lat, lon, MapCoordinatesDecimal), m.coordinates.text.Text)
+
+	m.MouseOut()
+	lat, lon = m.LatLonAt(fyne.NewPos(m.Size().Width/2, m.Size().Height/2))
+	assert.Equal(t, formatLatLon(lat, lon, MapCoordinatesDecimal), m.coordinates.text.Text)
+}
@@ -0,0 +1,107 @@
+package widget
+
+import (
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// DiskTileCache is a MapTileCache that stores tiles as files so they are available the next time an app runs.
+// Tiles are stored below the root directory in a folder per source, as zoom/x/y files.
+// Recently used tiles are also kept in memory to avoid reading and decoding them on each draw.
+type DiskTileCache struct {
+	root   string
+	memory *MemoryTileCache
+}
+
+type diskTileMeta struct {
+	ETag    string    `json:"etag,omitempty"`
+	Expires time.Time `json:"expires"`
+}
+
+// NewDiskTileCache creates a new tile cache that stores its tiles in the root directory.
+// The directory will be created when the first tile is stored.
+func NewDiskTileCache(root string) *DiskTileCache {
+	return &DiskTileCache{root: root, memory: NewMemoryTileCache(defaultTileCacheSize)}
+}
+
+// Get returns the tile stored for the key, checking memory first and then the disk.
+func (c *DiskTileCache) Get(key MapTileKey) (*MapTile, bool) {
+	if tile, ok := c.memory.Get(key); ok {
+		return tile, true
+	}
+
+	path := c.tilePath(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			fyne.LogError("Failed to read cached tile", err)
+		}
+		return nil, false
+	}
+
+	tile := &MapTile{Data: data}
+	if meta, err := os.ReadFile(path + ".meta"); err == nil {
+		var info diskTileMeta
+		if json.Unmarshal(meta, &info) == nil {
+			tile.ETag = info.ETag
+			tile.Expires = info.Expires
+		}
+	} // tiles without metadata were preloaded and never expire
+
+	c.memory.Put(key, tile)
+	return tile, true
+}
+
+// Preload adds encoded tile data to the cache, for example from tiles packaged with an app.
+// Preloaded tiles never expire so they will not be checked with the tile server.
+func (c *DiskTileCache) Preload(source string, zoom, x, y int, data []byte) error {
+	return c.write(MapTileKey{Source: source, Zoom: zoom, X: x, Y: y}, &MapTile{Data: data})
+}
+
+// Put stores a tile in memory and writes it to disk.
+func (c *DiskTileCache) Put(key MapTileKey, tile *MapTile) {
+	if err := c.write(key, tile); err != nil {
+		fyne.LogError("Failed to write cached tile", err)
+	}
+}
+
+func (c *DiskTileCache) tilePath(key MapTileKey) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key.Source))
+	source := strconv.FormatUint(h.Sum64(), 16)
+
+	return filepath.Join(c.root, source, strconv.Itoa(key.Zoom), strconv.Itoa(key.X), strconv.Itoa(key.Y))
+}
+
+func (c *DiskTileCache) write(key MapTileKey, tile *MapTile) error {
+	c.memory.Put(key, tile)
+
+	path := c.tilePath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, tile.Data, 0o644); err != nil {
+		return err
+	}
+
+	if tile.ETag == "" && tile.Expires.IsZero() {
+		err := os.Remove(path + ".meta")
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	meta, err := json.Marshal(&diskTileMeta{ETag: tile.ETag, Expires: tile.Expires})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path+".meta", meta, 0o644)
+}
@@ -0,0 +1,221 @@
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultDownloadWorkers is the number of tiles downloaded at the same time if not configured,
+// the most connections allowed by the OpenStreetMap tile usage policy.
+const defaultDownloadWorkers = 2
+
+// MapTileDownloader fetches all the tiles covering an area of the map into a tile cache,
+// so that they are available when a device is offline.
+// Use a cache that keeps its tiles, such as a DiskTileCache, and pass the same cache to the map using WithTileCache.
+//
+// Many tile servers, including the OpenStreetMap servers, do not allow bulk downloading.
+// Check the usage policy of the tile server and set RateLimit to stay within it.
+type MapTileDownloader struct {
+	// Source is the tile source to download from, as described by WithTileSource.
+	Source string
+	// Client is used to make the requests, if nil the default HTTP client is used.
+	Client *http.Client
+	// Cache is where the downloaded tiles are stored.
+	Cache MapTileCache
+
+	// Workers is the number of tiles that are requested at the same time, if not set 2 is used.
+	Workers int
+	// RateLimit is the most tiles requested each second, or 0 for no limit.
+	RateLimit float64
+
+	// OnProgress is called after each tile is stored or fails to download.
+	// It is called from the goroutine doing the download, so use fyne.Do to update any widgets.
+	OnProgress func(MapDownloadProgress) `json:"-"`
+}
+
+// MapDownloadProgress is how much of a MapDownload has completed.
+type MapDownloadProgress struct {
+	Done   int // the tiles that were downloaded, or were already in the cache
+	Failed int // the tiles that could not be downloaded
+	Total  int // the number of tiles in the download
+}
+
+// MapDownload is a download of map tiles running in the background, as returned by MapTileDownloader.Start.
+type MapDownload struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	notify   sync.Mutex // keeps progress callbacks in order
+	lock     sync.Mutex
+	progress MapDownloadProgress
+	firstErr error
+	err      error
+}
+
+// CountMapTiles returns the number of tiles that cover an area of the map at each zoom level from fromZoom to toZoom.
+// This can be used to estimate the time and space needed before starting a download.
+func CountMapTiles(bounds MapBounds, fromZoom, toZoom int) int {
+	count := 0
+	for zoom := max(fromZoom, 0); zoom <= min(toZoom, maxZoom); zoom++ {
+		minX, minY, maxX, maxY := tileRange(bounds, zoom)
+		count += (maxX - minX + 1) * (maxY - minY + 1)
+	}
+	return count
+}
+
+// Start begins downloading the tiles covering an area of the map at each zoom level from fromZoom to toZoom.
+// Tiles that are already in the cache and have not expired are not downloaded again,
+// so a download that was cancelled or failed can be resumed by starting it again.
+func (d *MapTileDownloader) Start(bounds MapBounds, fromZoom, toZoom int) *MapDownload {
+	ctx, cancel := context.WithCancel(context.Background())
+	dl := &MapDownload{cancel: cancel, done: make(chan struct{})}
+	dl.progress.Total = CountMapTiles(bounds, fromZoom, toZoom)
+
+	go dl.run(ctx, d, bounds, fromZoom, toZoom)
+	return dl
+}
+
+// Cancel stops the download. Tiles that have already been downloaded remain in the cache.
+func (dl *MapDownload) Cancel() {
+	dl.cancel()
+}
+
+// Progress returns how much of the download has completed.
+func (dl *MapDownload) Progress() MapDownloadProgress {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	return dl.progress
+}
+
+// Wait blocks until the download finishes.
+// It returns context.Canceled if the download was cancelled, or an error if any tiles failed to download.
+func (dl *MapDownload) Wait() error {
+	<-dl.done
+	return dl.err
+}
+
+func (dl *MapDownload) run(ctx context.Context, d *MapTileDownloader, bounds MapBounds, fromZoom, toZoom int) {
+	defer close(dl.done)
+	defer dl.cancel()
+	if d.Cache == nil {
+		dl.err = errors.New("no tile cache provided")
+		return
+	}
+
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var limit <-chan time.Time
+	if d.RateLimit > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / d.RateLimit))
+		defer ticker.Stop()
+		limit = ticker.C
+	}
+
+	count := d.Workers
+	if count <= 0 {
+		count = defaultDownloadWorkers
+	}
+	keys := make(chan MapTileKey)
+	var workers sync.WaitGroup
+	for i := 0; i < count; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for key := range keys {
+				_, err := getTile(ctx, d.Source, key.X, key.Y, key.Zoom, client, d.Cache)
+				if ctx.Err() == nil {
+					dl.tileDone(d, err)
+				}
+			}
+		}()
+	}
+
+	eachTile(bounds, fromZoom, toZoom, func(zoom, x, y int) bool {
+		key := MapTileKey{Source: d.Source, Zoom: zoom, X: x, Y: y}
+		if tile, ok := d.Cache.Get(key); ok && !tile.Expired() {
+			dl.tileDone(d, nil)
+			return ctx.Err() == nil
+		}
+
+		if limit != nil {
+			select {
+			case <-limit:
+			case <-ctx.Done():
+				return false
+			}
+		}
+		select {
+		case keys <- key:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+	close(keys)
+	workers.Wait()
+
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+	if err := ctx.Err(); err != nil {
+		dl.err = err
+	} else if dl.progress.Failed > 0 {
+		dl.err = fmt.Errorf("%d of %d map tiles failed to download: %w",
+			dl.progress.Failed, dl.progress.Total, dl.firstErr)
+	}
+}
+
+// tileDone counts a finished tile and reports the progress.
+func (dl *MapDownload) tileDone(d *MapTileDownloader, err error) {
+	dl.notify.Lock()
+	defer dl.notify.Unlock()
+
+	dl.lock.Lock()
+	if err != nil {
+		dl.progress.Failed++
+		if dl.firstErr == nil {
+			dl.firstErr = err
+		}
+	} else {
+		dl.progress.Done++
+	}
+	progress := dl.progress
+	dl.lock.Unlock()
+
+	if d.OnProgress != nil {
+		d.OnProgress(progress)
+	}
+}
+
+// eachTile calls fn with each tile that covers an area of the map between two zoom levels, stopping if it returns false.
+func eachTile(bounds MapBounds, fromZoom, toZoom int, fn func(zoom, x, y int) bool) {
+	for zoom := max(fromZoom, 0); zoom <= min(toZoom, maxZoom); zoom++ {
+		minX, minY, maxX, maxY := tileRange(bounds, zoom)
+		for y := minY; y <= maxY; y++ {
+			for x := minX; x <= maxX; x++ {
+				if !fn(zoom, x, y) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// tileRange returns the first and last tile positions covering an area of the map at a zoom level.
+func tileRange(bounds MapBounds, zoom int) (minX, minY, maxX, maxY int) {
+	last := 1<<zoom - 1
+	clamp := func(v float64) int {
+		return min(max(int(math.Floor(v)), 0), last)
+	}
+
+	x1, y1 := latLonToTile(math.Min(bounds.MaxLat, maxLatitude), bounds.MinLon, zoom)
+	x2, y2 := latLonToTile(math.Max(bounds.MinLat, -maxLatitude), bounds.MaxLon, zoom)
+	return clamp(x1), clamp(y1), clamp(x2), clamp(y2)
+}
@@ -0,0 +1,106 @@
+package widget
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountMapTiles(t *testing.T) {
+	world := MapBounds{MinLat: -90, MinLon: -180, MaxLat: 90, MaxLon: 180}
+	assert.Equal(t, 1+4+16, CountMapTiles(world, 0, 2))
+	assert.Equal(t, 16, CountMapTiles(world, 2, 2))
+	assert.Equal(t, 0, CountMapTiles(world, 3, 2))
+
+	edinburgh := MapBounds{MinLat: 55.90, MinLon: -3.25, MaxLat: 55.99, MaxLon: -3.10}
+	assert.Equal(t, 1, CountMapTiles(edinburgh, 0, 0))
+	assert.Equal(t, 2*3, CountMapTiles(edinburgh, 12, 12))
+	minX, minY, maxX, maxY := tileRange(edinburgh, 12)
+	assert.Equal(t, []int{2011, 1275, 2012, 1277}, []int{minX, minY, maxX, maxY})
+}
+
+func TestMapTileDownloader_Start(t *testing.T) {
+	data := testTileData(t)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if strings.HasPrefix(r.URL.Path, "/2/3/") { // a missing tile
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	var progress []MapDownloadProgress
+	cache := NewMemoryTileCache(100)
+	d := &MapTileDownloader{Source: server.URL + "/{z}/{x}/{y}.png", Client: server.Client(), Cache: cache,
+		OnProgress: func(p MapDownloadProgress) { progress = append(progress, p) }}
+	world := MapBounds{MinLat: -90, MinLon: -180, MaxLat: 90, MaxLon: 180}
+	dl := d.Start(world, 0, 2)
+	err := dl.Wait()
+	assert.ErrorContains(t, err, "4 of 21 map tiles failed to download")
+	assert.Equal(t, MapDownloadProgress{Done: 17, Failed: 4, Total: 21}, dl.Progress())
+	assert.Len(t, progress, 21)
+	assert.Equal(t, dl.Progress(), progress[20])
+	assert.Equal(t, 17, cache.Len())
+	assert.Equal(t, int32(21), requests.Load())
+
+	// tiles already in the cache are not requested again
+	progress = nil
+	dl = d.Start(world, 0, 2)
+	assert.Error(t, dl.Wait())
+	assert.Equal(t, MapDownloadProgress{Done: 17, Failed: 4, Total: 21}, dl.Progress())
+	assert.Equal(t, int32(25), requests.Load())
+}
+
+func TestMapTileDownloader_Cancel(t *testing.T) {
+	data := testTileData(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	d := &MapTileDownloader{Source: server.URL + "/{z}/{x}/{y}.png", Client: server.Client(),
+		Cache: NewMemoryTileCache(100), RateLimit: 1000}
+	started := make(chan struct{})
+	d.OnProgress = func(p MapDownloadProgress) {
+		if p.Done == 3 {
+			close(started)
+		}
+	}
+	dl := d.Start(MapBounds{MinLat: -90, MinLon: -180, MaxLat: 90, MaxLon: 180}, 0, 4)
+	<-started
+	dl.Cancel()
+	assert.ErrorIs(t, dl.Wait(), context.Canceled)
+	p := dl.Progress()
+	assert.Less(t, p.Done, p.Total)
+	assert.GreaterOrEqual(t, p.Done, 3)
+}
+
+func TestMapTileDownloader_RateLimit(t *testing.T) {
+	data := testTileData(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	d := &MapTileDownloader{Source: server.URL + "/{z}/{x}/{y}.png", Client: server.Client(),
+		Cache: NewMemoryTileCache(100), RateLimit: 50, Workers: 4}
+	start := time.Now()
+	dl := d.Start(MapBounds{MinLat: -90, MinLon: -180, MaxLat: 90, MaxLon: 180}, 0, 1)
+	assert.NoError(t, dl.Wait())
+	assert.GreaterOrEqual(t, time.Since(start), 5*20*time.Millisecond)
+	assert.Equal(t, MapDownloadProgress{Done: 5, Total: 5}, dl.Progress())
+}
+
+func TestMapTileDownloader_NoCache(t *testing.T) {
+	d := &MapTileDownloader{Source: "https://tile.example.com/{z}/{x}/{y}.png"}
+	assert.Error(t, d.Start(MapBounds{MaxLat: 1, MaxLon: 1}, 0, 1).Wait())
+}
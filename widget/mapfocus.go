@@ -0,0 +1,170 @@
+package widget
+
+import (
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+)
+
+// keyPanDistance is how far an arrow key moves the map, in canvas units.
+const keyPanDistance = tileSize / 4
+
+// FocusGained is called when the map has been given focus, it shows a focus outline.
+func (m *Map) FocusGained() {
+	m.focused = true
+	m.updateFocus()
+}
+
+// FocusLost is called when the map has lost focus.
+func (m *Map) FocusLost() {
+	m.focused = false
+	m.shiftDown = false
+	m.focusMarker(nil)
+	m.updateFocus()
+}
+
+// TypedRune zooms the map in with '+' or '=' and out with '-'.
+func (m *Map) TypedRune(r rune) {
+	switch r {
+	case '+', '=':
+		m.ZoomInAnimated()
+	case '-', '_':
+		m.ZoomOutAnimated()
+	}
+}
+
+// TypedKey pans the map with the arrow keys and moves between markers with Tab.
+// Return or Space taps the focused marker and Escape returns focus to the map.
+func (m *Map) TypedKey(ev *fyne.KeyEvent) {
+	switch ev.Name {
+	case fyne.KeyUp:
+		m.keyPan(0, keyPanDistance)
+	case fyne.KeyDown:
+		m.keyPan(0, -keyPanDistance)
+	case fyne.KeyLeft:
+		m.keyPan(keyPanDistance, 0)
+	case fyne.KeyRight:
+		m.keyPan(-keyPanDistance, 0)
+	case fyne.KeyTab:
+		if m.shiftDown {
+			m.focusNextMarker(-1)
+		} else {
+			m.focusNextMarker(1)
+		}
+	case fyne.KeyReturn, fyne.KeyEnter, fyne.KeySpace:
+		if m.focusedMarker != nil {
+			m.focusedMarker.tapped()
+		}
+	case fyne.KeyEscape:
+		m.focusMarker(nil)
+	}
+}
+
+// KeyDown tracks the shift key so that Shift+Tab moves back through the markers.
+func (m *Map) KeyDown(ev *fyne.KeyEvent) {
+	if ev.Name == desktop.KeyShiftLeft || ev.Name == desktop.KeyShiftRight {
+		m.shiftDown = true
+	}
+}
+
+// KeyUp tracks the shift key so that Shift+Tab moves back through the markers.
+func (m *Map) KeyUp(ev *fyne.KeyEvent) {
+	if ev.Name == desktop.KeyShiftLeft || ev.Name == desktop.KeyShiftRight {
+		m.shiftDown = false
+	}
+}
+
+// AcceptsTab returns true while Tab will move to another marker,
+// after the last marker focus moves on to the next widget as usual.
+func (m *Map) AcceptsTab() bool {
+	markers := m.shownMarkers()
+	if len(markers) == 0 {
+		return false
+	}
+
+	pos := slices.Index(markers, m.focusedMarker)
+	if m.shiftDown {
+		return pos > 0
+	}
+	return pos < len(markers)-1
+}
+
+// requestFocus gives the map keyboard focus when it is tapped, on devices with a keyboard.
+func (m *Map) requestFocus() {
+	if fyne.CurrentDevice().IsMobile() {
+		return
+	}
+	if c := fyne.CurrentApp().Driver().CanvasForObject(m); c != nil {
+		c.Focus(m)
+	}
+}
+
+func (m *Map) keyPan(dx, dy float32) {
+	m.stopZoomAnimation()
+	m.panBy(dx, dy)
+	m.Refresh()
+}
+
+// focusNextMarker moves the focus forward or back through the markers that are shown, bringing it into view.
+func (m *Map) focusNextMarker(step int) {
+	markers := m.shownMarkers()
+	if len(markers) == 0 {
+		return
+	}
+
+	pos := slices.Index(markers, m.focusedMarker)
+	if pos < 0 && step < 0 {
+		pos = len(markers)
+	}
+	pos = (pos + step + len(markers)) % len(markers)
+	marker := markers[pos]
+	m.focusMarker(marker)
+
+	size := m.Size()
+	at := m.PositionOf(marker.obj.Lat(), marker.obj.Lon())
+	if at.X < 0 || at.Y < 0 || at.X > size.Width || at.Y > size.Height {
+		m.PanToLatLon(marker.obj.Lat(), marker.obj.Lon())
+	}
+}
+
+// focusMarker shows the focus state on a marker, or none if it is nil.
+func (m *Map) focusMarker(marker *mapMarker) {
+	if m.focusedMarker == marker {
+		return
+	}
+
+	if m.focusedMarker != nil {
+		m.focusedMarker.setFocused(false)
+	}
+	m.focusedMarker = marker
+	if marker != nil {
+		marker.setFocused(true)
+	}
+}
+
+// shownMarkers returns the markers that are shown individually, and not grouped into a cluster.
+func (m *Map) shownMarkers() []*mapMarker {
+	var markers []*mapMarker
+	for _, o := range m.markers.Objects {
+		if marker, ok := o.(*mapMarker); ok {
+			markers = append(markers, marker)
+		}
+	}
+	return markers
+}
+
+func (m *Map) updateFocus() {
+	if m.focusBorder == nil {
+		return
+	}
+
+	m.focusBorder.StrokeColor = theme.ColorForWidget(theme.ColorNameFocus, m)
+	if m.focused {
+		m.focusBorder.Show()
+	} else {
+		m.focusBorder.Hide()
+	}
+	m.focusBorder.Refresh()
+}
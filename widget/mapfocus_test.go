@@ -0,0 +1,89 @@
+package widget
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap_KeyboardPanZoom(t *testing.T) {
+	test.NewTempApp(t)
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999))
+	w := test.NewTempWindow(t, m)
+	w.Resize(fyne.NewSize(400, 400))
+	m.Zoom(9)
+
+	test.TapAt(m, fyne.NewPos(100, 100))
+	assert.Equal(t, m, w.Canvas().Focused())
+	assert.True(t, m.focusBorder.Visible())
+
+	middle := fyne.NewPos(m.Size().Width/2, m.Size().Height/2)
+	lat, _ := m.LatLonAt(middle)
+	north, _ := m.LatLonAt(middle.SubtractXY(0, keyPanDistance))
+	_, east := m.LatLonAt(middle.AddXY(keyPanDistance, 0))
+	m.TypedKey(&fyne.KeyEvent{Name: fyne.KeyUp})
+	newLat, _ := m.LatLonAt(middle)
+	assert.InDelta(t, north, newLat, 0.0001)
+	m.TypedKey(&fyne.KeyEvent{Name: fyne.KeyDown})
+	m.TypedKey(&fyne.KeyEvent{Name: fyne.KeyRight})
+	newLat, newLon := m.LatLonAt(middle)
+	assert.InDelta(t, lat, newLat, 0.0001)
+	assert.InDelta(t, east, newLon, 0.0001)
+
+	assert.Equal(t, m, w.Canvas().Focused())
+	m.TypedRune('+')
+	assert.Equal(t, 10.0, m.ZoomLevel())
+	m.TypedRune('-')
+	m.TypedRune('-')
+	assert.Equal(t, 8.0, m.ZoomLevel())
+
+	w.Canvas().Unfocus()
+	assert.False(t, m.focusBorder.Visible())
+}
+
+func TestMap_KeyboardMarkers(t *testing.T) {
+	test.NewTempApp(t)
+	first := NewMapMarker(55.9486, -3.1999, "Castle")
+	second := NewMapMarker(55.9533, -3.1883, "Station")
+	far := NewMapMarker(51.5072, -0.1276, "London")
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999), WithMapMarkers([]MapMarker{first, second, far}))
+	var tapped MapMarker
+	m.OnMarkerTapped = func(marker MapMarker) { tapped = marker }
+	w := test.NewTempWindow(t, m)
+	w.Resize(fyne.NewSize(400, 400))
+	m.Zoom(12)
+	w.Canvas().Focus(m)
+
+	assert.True(t, m.AcceptsTab())
+	m.TypedKey(&fyne.KeyEvent{Name: fyne.KeyTab})
+	assert.Equal(t, m.markerObjs[0], m.focusedMarker)
+	assert.True(t, m.markerObjs[0].item.focus.Visible())
+	m.TypedKey(&fyne.KeyEvent{Name: fyne.KeyTab})
+	assert.Equal(t, m.markerObjs[1], m.focusedMarker)
+	assert.False(t, m.markerObjs[0].item.focus.Visible())
+
+	// moving to a marker out of view pans the map to it
+	m.TypedKey(&fyne.KeyEvent{Name: fyne.KeyTab})
+	assert.Equal(t, m.markerObjs[2], m.focusedMarker)
+	lat, lon := m.LatLonAt(fyne.NewPos(m.Size().Width/2, m.Size().Height/2))
+	assert.InDelta(t, 51.5072, lat, 0.001)
+	assert.InDelta(t, -0.1276, lon, 0.001)
+	assert.False(t, m.AcceptsTab()) // the next Tab leaves the map
+
+	m.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+	assert.Equal(t, far, tapped)
+
+	m.KeyDown(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+	assert.True(t, m.AcceptsTab())
+	m.TypedKey(&fyne.KeyEvent{Name: fyne.KeyTab})
+	assert.Equal(t, m.markerObjs[1], m.focusedMarker)
+	m.KeyUp(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+
+	m.TypedKey(&fyne.KeyEvent{Name: fyne.KeyEscape})
+	assert.Nil(t, m.focusedMarker)
+	assert.False(t, m.markerObjs[1].item.focus.Visible())
+}
@@ -0,0 +1,218 @@
+package widget
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/twpayne/go-geom"
+	"github.com/twpayne/go-geom/encoding/geojson"
+)
+
+// MapOverlayStyle describes how a line or area loaded from a data file should be drawn.
+type MapOverlayStyle struct {
+	FillColor   color.Color // only used for areas
+	StrokeColor color.Color
+	StrokeWidth float32
+}
+
+// MapDataOptions configures how data files are turned into map markers and overlays.
+type MapDataOptions struct {
+	// TitleProperty is the property used for marker titles. If empty "name" or "title" is used.
+	TitleProperty string
+	// Style returns the style for a line or area based on its properties.
+	// If not set the simplestyle properties ("stroke", "stroke-width", "fill" etc) are used.
+	Style func(properties map[string]interface{}) MapOverlayStyle
+}
+
+// LoadGeoJSON reads a GeoJSON FeatureCollection, Feature or geometry and returns markers for each point
+// and overlays for each line and area. These can be shown using SetMarkers and SetOverlays on a Map.
+func LoadGeoJSON(r io.Reader, opts *MapDataOptions) ([]MapMarker, []MapOverlay, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	var obj geoJSONObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, nil, err
+	}
+
+	if opts == nil {
+		opts = &MapDataOptions{}
+	}
+	l := &mapDataLoader{opts: opts}
+	if obj.Type == "Feature" || obj.Type == "FeatureCollection" {
+		err = l.addGeoJSON(&obj)
+	} else {
+		obj.Geometry = data
+		err = l.addGeoJSONGeometry(&obj)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return l.markers, l.overlays, nil
+}
+
+// SimpleStyle returns the style for a feature using the simplestyle-spec properties.
+// Missing properties use the defaults from https://github.com/mapbox/simplestyle-spec.
+func SimpleStyle(properties map[string]interface{}) MapOverlayStyle {
+	stroke := parseStyleColor(properties["stroke"], color.NRGBA{R: 0x55, G: 0x55, B: 0x55, A: 0xff})
+	fill := parseStyleColor(properties["fill"], color.NRGBA{R: 0x55, G: 0x55, B: 0x55, A: 0xff})
+
+	return MapOverlayStyle{
+		StrokeColor: withOpacity(stroke, parseStyleNumber(properties["stroke-opacity"], 1)),
+		FillColor:   withOpacity(fill, parseStyleNumber(properties["fill-opacity"], 0.6)),
+		StrokeWidth: float32(parseStyleNumber(properties["stroke-width"], 2)),
+	}
+}
+
+type geoJSONObject struct {
+	Type       string                 `json:"type"`
+	Features   []*geoJSONObject       `json:"features"`
+	Geometry   json.RawMessage        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type mapDataLoader struct {
+	opts     *MapDataOptions
+	markers  []MapMarker
+	overlays []MapOverlay
+}
+
+func (l *mapDataLoader) addGeoJSON(obj *geoJSONObject) error {
+	switch obj.Type {
+	case "FeatureCollection":
+		for _, f := range obj.Features {
+			if err := l.addGeoJSON(f); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "Feature":
+		return l.addGeoJSONGeometry(obj)
+	}
+
+	return fmt.Errorf("unsupported GeoJSON type %q", obj.Type)
+}
+
+func (l *mapDataLoader) addGeoJSONGeometry(obj *geoJSONObject) error {
+	if len(obj.Geometry) == 0 || string(obj.Geometry) == "null" {
+		return nil // features without a location are allowed
+	}
+
+	var g geojson.Geometry
+	if err := json.Unmarshal(obj.Geometry, &g); err != nil {
+		return err
+	}
+	shape, err := g.Decode()
+	if err != nil {
+		return err
+	}
+	return l.addGeometry(shape, obj.Properties)
+}
+
+func (l *mapDataLoader) addGeometry(shape geom.T, props map[string]interface{}) error {
+	switch g := shape.(type) {
+	case *geom.Point:
+		l.addPoint(g.Y(), g.X(), props)
+	case *geom.MultiPoint:
+		for i := 0; i < g.NumPoints(); i++ {
+			p := g.Point(i)
+			l.addPoint(p.Y(), p.X(), props)
+		}
+	case *geom.LineString:
+		l.addLine(g, props)
+	case *geom.MultiLineString:
+		for i := 0; i < g.NumLineStrings(); i++ {
+			l.addLine(g.LineString(i), props)
+		}
+	case *geom.Polygon:
+		l.addArea(g, props)
+	case *geom.MultiPolygon:
+		for i := 0; i < g.NumPolygons(); i++ {
+			l.addArea(g.Polygon(i), props)
+		}
+	case *geom.GeometryCollection:
+		for _, child := range g.Geoms() {
+			if err := l.addGeometry(child, props); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported geometry type %T", shape)
+	}
+	return nil
+}
+
+func (l *mapDataLoader) addArea(shape *geom.Polygon, props map[string]interface{}) {
+	style := l.style(props)
+	l.overlays = append(l.overlays, NewMapPolygon(shape, style.FillColor, style.StrokeColor, style.StrokeWidth))
+}
+
+func (l *mapDataLoader) addLine(path *geom.LineString, props map[string]interface{}) {
+	style := l.style(props)
+	l.overlays = append(l.overlays, NewMapPolyline(path, style.StrokeColor, style.StrokeWidth))
+}
+
+func (l *mapDataLoader) addPoint(lat, lon float64, props map[string]interface{}) {
+	l.markers = append(l.markers, NewMapMarker(lat, lon, l.title(props)))
+}
+
+func (l *mapDataLoader) style(props map[string]interface{}) MapOverlayStyle {
+	if l.opts.Style != nil {
+		return l.opts.Style(props)
+	}
+	return SimpleStyle(props)
+}
+
+func (l *mapDataLoader) title(props map[string]interface{}) string {
+	names := []string{"name", "title"}
+	if l.opts.TitleProperty != "" {
+		names = []string{l.opts.TitleProperty}
+	}
+
+	for _, name := range names {
+		if value, ok := props[name]; ok && value != nil {
+			return fmt.Sprint(value)
+		}
+	}
+	return ""
+}
+
+// parseStyleColor reads a CSS style hex colour such as "#ff0000" or "#f00".
+func parseStyleColor(value interface{}, fallback color.NRGBA) color.NRGBA {
+	str, ok := value.(string)
+	if !ok {
+		return fallback
+	}
+	hex := strings.TrimPrefix(str, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return fallback
+	}
+	return color.NRGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 0xff}
+}
+
+func parseStyleNumber(value interface{}, fallback float64) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return fallback
+}
+
+func withOpacity(c color.NRGBA, opacity float64) color.NRGBA {
+	c.A = uint8(255 * math.Max(0, math.Min(1, opacity)))
+	return c
+}
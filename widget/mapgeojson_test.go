@@ -0,0 +1,67 @@
+package widget
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testGeoJSON = `{
+  "type": "FeatureCollection",
+  "features": [
+    {"type": "Feature", "properties": {"name": "Castle", "id": 12},
+      "geometry": {"type": "Point", "coordinates": [-3.1999, 55.9486]}},
+    {"type": "Feature", "properties": {"stroke": "#f00", "stroke-width": 4},
+      "geometry": {"type": "LineString", "coordinates": [[-3.2, 55.9], [-3.1, 55.95]]}},
+    {"type": "Feature", "properties": {"fill": "#0000ff", "fill-opacity": 0.5, "kind": "zone"},
+      "geometry": {"type": "MultiPolygon", "coordinates": [
+        [[[0, 0], [1, 0], [1, 1], [0, 0]]],
+        [[[2, 2], [3, 2], [3, 3], [2, 2]]]
+      ]}},
+    {"type": "Feature", "properties": {"name": "Unplaced"}, "geometry": null}
+  ]
+}`
+
+func TestLoadGeoJSON(t *testing.T) {
+	markers, overlays, err := LoadGeoJSON(strings.NewReader(testGeoJSON), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(markers))
+	assert.Equal(t, "Castle", markers[0].Title())
+	assert.Equal(t, 55.9486, markers[0].Lat())
+	assert.Equal(t, -3.1999, markers[0].Lon())
+
+	assert.Equal(t, 3, len(overlays))
+	line := overlays[0].(*MapPolyline)
+	assert.Equal(t, color.NRGBA{R: 0xff, A: 0xff}, line.StrokeColor)
+	assert.Equal(t, float32(4), line.StrokeWidth)
+	area := overlays[1].(*MapPolygon)
+	assert.Equal(t, color.NRGBA{B: 0xff, A: 0x7f}, area.FillColor)
+	assert.Equal(t, color.NRGBA{R: 0x55, G: 0x55, B: 0x55, A: 0xff}, area.StrokeColor)
+}
+
+func TestLoadGeoJSON_Options(t *testing.T) {
+	opts := &MapDataOptions{TitleProperty: "id", Style: func(props map[string]interface{}) MapOverlayStyle {
+		if props["kind"] == "zone" {
+			return MapOverlayStyle{FillColor: color.White}
+		}
+		return MapOverlayStyle{StrokeColor: color.Black, StrokeWidth: 1}
+	}}
+	markers, overlays, err := LoadGeoJSON(strings.NewReader(testGeoJSON), opts)
+	assert.Nil(t, err)
+	assert.Equal(t, "12", markers[0].Title())
+	assert.Equal(t, color.Black, overlays[0].(*MapPolyline).StrokeColor)
+	assert.Equal(t, color.White, overlays[2].(*MapPolygon).FillColor)
+}
+
+func TestLoadGeoJSON_Geometry(t *testing.T) {
+	markers, overlays, err := LoadGeoJSON(strings.NewReader(`{"type": "MultiPoint", "coordinates": [[1, 2], [3, 4]]}`), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(markers))
+	assert.Equal(t, 0, len(overlays))
+	assert.Equal(t, 4.0, markers[1].Lat())
+
+	_, _, err = LoadGeoJSON(strings.NewReader(`{"type": "Circle"}`), nil)
+	assert.NotNil(t, err)
+}
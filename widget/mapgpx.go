@@ -0,0 +1,69 @@
+package widget
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/twpayne/go-geom"
+)
+
+type gpxFile struct {
+	Waypoints []gpxPoint `xml:"wpt"`
+	Routes    []struct {
+		Name   string     `xml:"name"`
+		Type   string     `xml:"type"`
+		Points []gpxPoint `xml:"rtept"`
+	} `xml:"rte"`
+	Tracks []struct {
+		Name     string `xml:"name"`
+		Type     string `xml:"type"`
+		Segments []struct {
+			Points []gpxPoint `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+type gpxPoint struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Name string  `xml:"name"`
+	Desc string  `xml:"desc"`
+	Type string  `xml:"type"`
+}
+
+// LoadGPX reads a GPX file and returns markers for each waypoint and line overlays for each route and track.
+// The properties passed to MapDataOptions are "name", "desc" and "type" from the GPX data,
+// and "gpx" which is set to "waypoint", "route" or "track".
+func LoadGPX(r io.Reader, opts *MapDataOptions) ([]MapMarker, []MapOverlay, error) {
+	var file gpxFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, nil, err
+	}
+
+	if opts == nil {
+		opts = &MapDataOptions{}
+	}
+	l := &mapDataLoader{opts: opts}
+	for _, p := range file.Waypoints {
+		l.addPoint(p.Lat, p.Lon, map[string]interface{}{"name": p.Name, "desc": p.Desc, "type": p.Type,
+			"gpx": "waypoint"})
+	}
+	for _, rte := range file.Routes {
+		l.addLine(gpxPath(rte.Points), map[string]interface{}{"name": rte.Name, "type": rte.Type, "gpx": "route"})
+	}
+	for _, trk := range file.Tracks {
+		props := map[string]interface{}{"name": trk.Name, "type": trk.Type, "gpx": "track"}
+		for _, seg := range trk.Segments {
+			l.addLine(gpxPath(seg.Points), props)
+		}
+	}
+	return l.markers, l.overlays, nil
+}
+
+func gpxPath(points []gpxPoint) *geom.LineString {
+	flat := make([]float64, 0, len(points)*2)
+	for _, p := range points {
+		flat = append(flat, p.Lon, p.Lat)
+	}
+	return geom.NewLineStringFlat(geom.XY, flat)
+}
@@ -0,0 +1,48 @@
+package widget
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testGPX = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+  <wpt lat="55.9486" lon="-3.1999"><name>Castle</name></wpt>
+  <wpt lat="55.9527" lon="-3.1723"><name>Calton Hill</name></wpt>
+  <rte><name>Walk</name><rtept lat="55.9486" lon="-3.1999"/><rtept lat="55.9527" lon="-3.1723"/></rte>
+  <trk><name>Drive</name>
+    <trkseg><trkpt lat="55.9" lon="-3.2"/><trkpt lat="55.91" lon="-3.21"/><trkpt lat="55.92" lon="-3.2"/></trkseg>
+    <trkseg><trkpt lat="56" lon="-3"/><trkpt lat="56.1" lon="-3.1"/></trkseg>
+  </trk>
+</gpx>`
+
+func TestLoadGPX(t *testing.T) {
+	opts := &MapDataOptions{Style: func(props map[string]interface{}) MapOverlayStyle {
+		if props["gpx"] == "track" {
+			return MapOverlayStyle{StrokeColor: color.Black, StrokeWidth: 3}
+		}
+		return SimpleStyle(props)
+	}}
+	markers, overlays, err := LoadGPX(strings.NewReader(testGPX), opts)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(markers))
+	assert.Equal(t, "Calton Hill", markers[1].Title())
+	assert.Equal(t, 55.9527, markers[1].Lat())
+	assert.Equal(t, -3.1723, markers[1].Lon())
+
+	assert.Equal(t, 3, len(overlays))
+	route := overlays[0].(*MapPolyline)
+	assert.Equal(t, 2, route.Path.NumCoords())
+	assert.Equal(t, []float64{-3.1999, 55.9486}, []float64(route.Path.Coord(0)))
+	track := overlays[1].(*MapPolyline)
+	assert.Equal(t, 3, track.Path.NumCoords())
+	assert.Equal(t, color.Black, track.StrokeColor)
+}
+
+func TestLoadGPX_Invalid(t *testing.T) {
+	_, _, err := LoadGPX(strings.NewReader("not xml"), nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,201 @@
+package widget
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"slices"
+)
+
+// MapHeatmapPoint is a weighted location that adds to the density shown by a MapHeatmap.
+type MapHeatmapPoint struct {
+	Lat, Lon float64
+	Weight   float64
+}
+
+// MapHeatmap is a MapOverlay that shows the density of weighted points as a colour gradient,
+// such as the readings from many sensors. The density is worked out again at the current zoom level each time
+// the map is drawn, with nearby points grouped first so that thousands of points can be shown.
+type MapHeatmap struct {
+	Points []MapHeatmapPoint
+	// Radius is how far the weight of each point spreads, in canvas units.
+	Radius float32
+	// MaxWeight is the density that is drawn using the last colour of the gradient.
+	// If it is 0 the highest density that is visible is used.
+	MaxWeight float64
+	// Gradient is the colour ramp from low to high density, with colours evenly spaced.
+	// If it is empty a ramp from blue through green and yellow to red is used.
+	// Low densities also fade to transparent so the map can be seen where there are few points.
+	Gradient []color.Color
+
+	kernel       []float32 // the spread of a point of weight 1, in a square of side 2*kernelRadius+1
+	kernelRadius int
+	ramp         []color.NRGBA // the gradient as 256 colours
+	rampFrom     []color.Color
+}
+
+// DefaultHeatmapGradient is the colour ramp used by a MapHeatmap if no gradient is set.
+var DefaultHeatmapGradient = []color.Color{
+	color.NRGBA{B: 0xff, A: 0xff},
+	color.NRGBA{G: 0xff, B: 0xff, A: 0xff},
+	color.NRGBA{G: 0xff, A: 0xff},
+	color.NRGBA{R: 0xff, G: 0xff, A: 0xff},
+	color.NRGBA{R: 0xff, A: 0xff},
+}
+
+// NewMapHeatmap returns a new heatmap overlay of the points, where each point spreads over a radius in canvas units.
+func NewMapHeatmap(points []MapHeatmapPoint, radius float32) *MapHeatmap {
+	return &MapHeatmap{Points: points, Radius: radius}
+}
+
+// Draw renders the density of the points into the provided image.
+func (h *MapHeatmap) Draw(img draw.Image, proj MapProjection) {
+	radius := int(math.Round(float64(h.Radius * proj.Scale())))
+	if radius < 1 || len(h.Points) == 0 {
+		return
+	}
+
+	bounds := img.Bounds()
+	density := make([]float32, bounds.Dx()*bounds.Dy())
+	for _, cell := range h.groupPoints(proj, bounds, radius) {
+		h.spread(density, bounds, cell, radius)
+	}
+
+	top := float32(h.MaxWeight)
+	if top <= 0 {
+		for _, v := range density {
+			top = max(top, v)
+		}
+	}
+	if top <= 0 {
+		return
+	}
+
+	ramp := h.colorRamp()
+	heat := image.NewNRGBA(bounds)
+	for i, v := range density {
+		if v <= 0 {
+			continue
+		}
+		level := int(math.Min(float64(v/top), 1) * 255)
+		x, y := bounds.Min.X+i%bounds.Dx(), bounds.Min.Y+i/bounds.Dx()
+		heat.SetNRGBA(x, y, ramp[level])
+	}
+	draw.Draw(img, bounds, heat, bounds.Min, draw.Over)
+}
+
+// heatmapCell is the total weight of the points in part of the image, placed at their weighted middle.
+type heatmapCell struct {
+	x, y, weight float64
+}
+
+// groupPoints projects the points that are near the image and adds together those within a few pixels,
+// so that the cost of spreading them depends on the image size instead of the number of points.
+func (h *MapHeatmap) groupPoints(proj MapProjection, bounds image.Rectangle, radius int) []*heatmapCell {
+	size := math.Max(float64(radius)/4, 1)
+	area := expandRect(bounds, float64(radius))
+	cells := make(map[image.Point]*heatmapCell)
+	var list []*heatmapCell
+	for _, p := range h.Points {
+		if p.Weight <= 0 {
+			continue
+		}
+		x, y := proj.Pixel(p.Lat, p.Lon)
+		if x < area.minX || y < area.minY || x > area.maxX || y > area.maxY {
+			continue
+		}
+
+		key := image.Pt(int(math.Floor(x/size)), int(math.Floor(y/size)))
+		cell, ok := cells[key]
+		if !ok {
+			cell = &heatmapCell{}
+			cells[key] = cell
+			list = append(list, cell)
+		}
+		cell.x += x * p.Weight
+		cell.y += y * p.Weight
+		cell.weight += p.Weight
+	}
+
+	for _, cell := range list {
+		cell.x /= cell.weight
+		cell.y /= cell.weight
+	}
+	return list
+}
+
+// spread adds the weight of a cell to the density of the pixels around it.
+func (h *MapHeatmap) spread(density []float32, bounds image.Rectangle, cell *heatmapCell, radius int) {
+	kernel := h.kernelFor(radius)
+	side := radius*2 + 1
+	cx, cy := int(math.Round(cell.x)), int(math.Round(cell.y))
+	weight := float32(cell.weight)
+	width := bounds.Dx()
+
+	minX, maxX := max(cx-radius, bounds.Min.X), min(cx+radius, bounds.Max.X-1)
+	minY, maxY := max(cy-radius, bounds.Min.Y), min(cy+radius, bounds.Max.Y-1)
+	for y := minY; y <= maxY; y++ {
+		row := kernel[(y-cy+radius)*side:]
+		out := density[(y-bounds.Min.Y)*width:]
+		for x := minX; x <= maxX; x++ {
+			out[x-bounds.Min.X] += row[x-cx+radius] * weight
+		}
+	}
+}
+
+// kernelFor returns the spread of a point of weight 1 over a radius, which falls smoothly to 0 at the edge.
+func (h *MapHeatmap) kernelFor(radius int) []float32 {
+	if h.kernelRadius == radius {
+		return h.kernel
+	}
+
+	side := radius*2 + 1
+	kernel := make([]float32, side*side)
+	r2 := float64(radius * radius)
+	for y := -radius; y <= radius; y++ {
+		for x := -radius; x <= radius; x++ {
+			d := 1 - float64(x*x+y*y)/r2
+			if d > 0 {
+				kernel[(y+radius)*side+x+radius] = float32(d * d)
+			}
+		}
+	}
+	h.kernel, h.kernelRadius = kernel, radius
+	return kernel
+}
+
+// colorRamp returns 256 colours blended from the gradient, re-using the last result if the gradient is unchanged.
+func (h *MapHeatmap) colorRamp() []color.NRGBA {
+	gradient := h.Gradient
+	if len(gradient) == 0 {
+		gradient = DefaultHeatmapGradient
+	}
+	if h.ramp != nil && slices.Equal(h.rampFrom, gradient) {
+		return h.ramp
+	}
+
+	stops := make([]color.NRGBA, len(gradient))
+	for i, c := range gradient {
+		stops[i] = color.NRGBAModel.Convert(c).(color.NRGBA)
+	}
+
+	ramp := make([]color.NRGBA, 256)
+	for i := range ramp {
+		pos := float64(i) / 255 * float64(len(stops)-1)
+		from := int(pos)
+		to := min(from+1, len(stops)-1)
+		c := blendNRGBA(stops[from], stops[to], pos-float64(from))
+		c.A = uint8(float64(c.A) * math.Min(float64(i)/64, 1)) // fade out the lowest densities
+		ramp[i] = c
+	}
+	h.ramp, h.rampFrom = ramp, append([]color.Color(nil), gradient...)
+	return ramp
+}
+
+func blendNRGBA(a, b color.NRGBA, f float64) color.NRGBA {
+	mix := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + (float64(y)-float64(x))*f))
+	}
+	return color.NRGBA{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B), A: mix(a.A, b.A)}
+}
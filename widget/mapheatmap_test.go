@@ -0,0 +1,58 @@
+package widget
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapHeatmap_Draw(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 100, 100))
+	h := NewMapHeatmap([]MapHeatmapPoint{
+		{Lat: 30, Lon: 30, Weight: 1},
+		{Lat: 31, Lon: 30, Weight: 1}, // grouped with the first point
+		{Lat: 70, Lon: 70, Weight: 0.5},
+		{Lat: 300, Lon: 300, Weight: 10}, // outside the image
+	}, 10)
+	h.Draw(img, testProjection{})
+
+	// the densest point, between the grouped points, uses the last colour of the gradient
+	assert.Equal(t, color.NRGBA{R: 0xff, A: 0xff}, img.NRGBAAt(30, 31))
+	// the lighter point is a quarter of the density, near the second colour
+	light := img.NRGBAAt(70, 70)
+	assert.Zero(t, light.R)
+	assert.Greater(t, light.G, uint8(0xf0))
+	assert.Equal(t, uint8(0xff), light.B)
+	assert.Zero(t, img.NRGBAAt(50, 50).A) // too far from any point
+	edge := img.NRGBAAt(30, 39)
+	assert.Less(t, edge.A, uint8(0xff)) // low densities fade out
+	assert.Greater(t, edge.B, uint8(0))
+}
+
+func TestMapHeatmap_MaxWeight(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 100, 100))
+	h := NewMapHeatmap([]MapHeatmapPoint{{Lat: 50, Lon: 50, Weight: 1}}, 10)
+	h.MaxWeight = 2
+	h.Gradient = []color.Color{color.Black, color.White}
+	h.Draw(img, testProjection{})
+
+	assert.Equal(t, color.NRGBA{R: 0x7f, G: 0x7f, B: 0x7f, A: 0xff}, img.NRGBAAt(50, 50))
+}
+
+func TestMapHeatmap_ManyPoints(t *testing.T) {
+	points := make([]MapHeatmapPoint, 0, 10000)
+	for i := 0; i < 10000; i++ {
+		points = append(points, MapHeatmapPoint{Lat: float64(i % 100), Lon: float64(i / 100), Weight: 1})
+	}
+	h := NewMapHeatmap(points, 8)
+	groups := h.groupPoints(testProjection{}, image.Rect(0, 0, 100, 100), 8)
+	assert.Len(t, groups, 50*50) // cells of 2 pixels
+
+	img := image.NewNRGBA(image.Rect(0, 0, 100, 100))
+	h.Draw(img, testProjection{})
+	middle := img.NRGBAAt(50, 50)
+	assert.Equal(t, uint8(0xff), middle.R)
+	assert.Less(t, middle.G, uint8(0x10))
+}
@@ -17,6 +17,115 @@ type MapMarker interface {
 	Title() string
 }
 
+// MapMarkerRenderer can be implemented by a MapMarker to be drawn as something other than the default pin.
+type MapMarkerRenderer interface {
+	MapMarker
+
+	// MarkerObject returns the object to draw for this marker, or nil to use the default pin.
+	MarkerObject() fyne.CanvasObject
+	// MarkerAnchor returns the point within the object, from its top left, that is placed at the marker location.
+	MarkerAnchor() fyne.Position
+}
+
+// MapMarkerPopup can be implemented by a MapMarker to show more details when the marker is tapped.
+type MapMarkerPopup interface {
+	MapMarker
+
+	// PopupContent returns the content shown above the marker when it is tapped, or nil for no popup.
+	PopupContent() fyne.CanvasObject
+}
+
+// MapMarkerDraggable can be implemented by a MapMarker that the user can move by dragging it.
+type MapMarkerDraggable interface {
+	MapMarker
+
+	// MarkerDraggable returns true if the marker can currently be dragged.
+	MarkerDraggable() bool
+	// MarkerDragged is called with the new location as the marker is dragged, Lat and Lon should return it afterwards.
+	MarkerDragged(lat, lon float64)
+	// MarkerDragEnd is called with the final location when the user stops dragging the marker.
+	MarkerDragEnd(lat, lon float64)
+}
+
+// CustomMapMarker is a MapMarker with its own appearance and an optional popup shown when it is tapped.
+type CustomMapMarker struct {
+	Latitude, Longitude float64
+	Name                string // the title of this marker
+
+	// Object is drawn for the marker instead of the default pin, such as an icon or a coloured circle.
+	Object fyne.CanvasObject
+	// Anchor is the point within Object, from its top left, that is placed at the marker location.
+	Anchor fyne.Position
+	// Popup is shown above the marker when it is tapped, if set. This can hold any content, such as a detail card.
+	Popup fyne.CanvasObject
+
+	// Draggable allows the user to move the marker by dragging it.
+	Draggable bool
+	// OnDragged is called with the new location as the marker is dragged.
+	OnDragged func(lat, lon float64) `json:"-"`
+	// OnDragEnd is called with the final location when the user stops dragging the marker.
+	OnDragEnd func(lat, lon float64) `json:"-"`
+}
+
+// NewMapMarkerWithIcon returns a marker that is drawn using an icon instead of the default pin.
+// The bottom middle of the icon is placed at the marker location.
+func NewMapMarkerWithIcon(lat, lon float64, title string, icon fyne.Resource) *CustomMapMarker {
+	img := canvas.NewImageFromResource(icon)
+	img.SetMinSize(fyne.NewSquareSize(32))
+	return &CustomMapMarker{Latitude: lat, Longitude: lon, Name: title, Object: img, Anchor: fyne.NewPos(16, 32)}
+}
+
+// Lat returns the latitude of this marker.
+func (c *CustomMapMarker) Lat() float64 {
+	return c.Latitude
+}
+
+// Lon returns the longitude of this marker.
+func (c *CustomMapMarker) Lon() float64 {
+	return c.Longitude
+}
+
+// Title returns the title of this marker.
+func (c *CustomMapMarker) Title() string {
+	return c.Name
+}
+
+// MarkerObject returns the object drawn for this marker.
+func (c *CustomMapMarker) MarkerObject() fyne.CanvasObject {
+	return c.Object
+}
+
+// MarkerAnchor returns the point within the marker object that is placed at the marker location.
+func (c *CustomMapMarker) MarkerAnchor() fyne.Position {
+	return c.Anchor
+}
+
+// PopupContent returns the content shown when this marker is tapped.
+func (c *CustomMapMarker) PopupContent() fyne.CanvasObject {
+	return c.Popup
+}
+
+// MarkerDraggable returns true if the user can move this marker.
+func (c *CustomMapMarker) MarkerDraggable() bool {
+	return c.Draggable
+}
+
+// MarkerDragged moves the marker to the location it has been dragged to and calls OnDragged.
+func (c *CustomMapMarker) MarkerDragged(lat, lon float64) {
+	c.Latitude, c.Longitude = lat, lon
+	if c.OnDragged != nil {
+		c.OnDragged(lat, lon)
+	}
+}
+
+// MarkerDragEnd moves the marker to its final location and calls OnDragEnd.
+func (c *CustomMapMarker) MarkerDragEnd(lat, lon float64) {
+	c.Latitude, c.Longitude = lat, lon
+	if c.OnDragEnd != nil {
+		c.OnDragEnd(lat, lon)
+	}
+}
+
 type genericMapMarker struct {
 	lat   float64
 	lon   float64
@@ -45,6 +154,10 @@ type mapMarker struct {
 	title     *canvas.Text
 	obj       MapMarker
 	item      *mapMarkerItem
+	popup     *widget.PopUp
+	onTapped  func(MapMarker)
+	onDragged func(*mapMarker, *fyne.DragEvent)
+	onDragEnd func(*mapMarker)
 }
 
 //go:embed mapmarker.svg
@@ -71,14 +184,12 @@ func (m *mapMarker) setup() {
 	m.title = canvas.NewText(m.obj.Title(), theme.ColorForWidget(theme.ColorNamePrimary, m))
 	m.title.Hide()
 
-	m.item = newMapMarkerItem(func() {
-		if m.title.Hidden {
-			m.title.Show()
-		} else {
-			m.title.Hide()
-		}
-		m.container.Refresh()
-	})
+	m.item = newMapMarkerItem(m.tapped)
+	m.item.onDragged = m.dragged
+	m.item.onDragEnd = m.dragEnd
+	if r, ok := m.obj.(MapMarkerRenderer); ok {
+		m.item.obj = r.MarkerObject()
+	}
 
 	m.container = container.NewVBox(
 		m.title,
@@ -89,11 +200,66 @@ func (m *mapMarker) setup() {
 func (m *mapMarker) pinOffset() fyne.Position {
 	m.setup()
 	imgSize := m.item.Size()
+	anchor := fyne.NewPos(imgSize.Width/2, imgSize.Height+(imgSize.Height*.05)) // the tip of the pin
+	if r, ok := m.obj.(MapMarkerRenderer); ok && m.item.obj != nil {
+		anchor = r.MarkerAnchor()
+	}
 	if m.title.Hidden {
-		return fyne.NewPos(imgSize.Width/2, imgSize.Height+(imgSize.Height*.05))
+		return anchor
 	}
 	conSize := m.container.Size()
-	return fyne.NewPos(conSize.Width/2, conSize.Height+(imgSize.Height*.05))
+	return fyne.NewPos((conSize.Width-imgSize.Width)/2+anchor.X, conSize.Height-imgSize.Height+anchor.Y)
+}
+
+func (m *mapMarker) tapped() {
+	if p, ok := m.obj.(MapMarkerPopup); ok && p.PopupContent() != nil {
+		m.showPopup(p.PopupContent())
+	} else {
+		if m.title.Hidden {
+			m.title.Show()
+		} else {
+			m.title.Hide()
+		}
+		m.container.Refresh()
+	}
+
+	if m.onTapped != nil {
+		m.onTapped(m.obj)
+	}
+}
+
+// setFocused shows or hides the keyboard focus state of the marker.
+func (m *mapMarker) setFocused(focused bool) {
+	m.setup()
+	m.item.focused = focused
+	m.item.Refresh()
+}
+
+func (m *mapMarker) dragged(ev *fyne.DragEvent) {
+	if m.onDragged != nil {
+		m.onDragged(m, ev)
+	}
+}
+
+func (m *mapMarker) dragEnd() {
+	if m.onDragEnd != nil {
+		m.onDragEnd(m)
+	}
+}
+
+// showPopup displays the content in a pop up just above the marker.
+func (m *mapMarker) showPopup(content fyne.CanvasObject) {
+	c := fyne.CurrentApp().Driver().CanvasForObject(m)
+	if c == nil {
+		return
+	}
+	if m.popup == nil || m.popup.Content != content {
+		m.popup = widget.NewPopUp(content, c)
+	}
+
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(m.item)
+	size := m.popup.MinSize()
+	m.popup.ShowAtPosition(pos.Add(fyne.NewPos((m.item.Size().Width-size.Width)/2, -size.Height)))
 }
 
 func (m *mapMarker) MinSize() fyne.Size {
@@ -0,0 +1,155 @@
+package widget
+
+import (
+	"image/color"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapMarker_CustomObject(t *testing.T) {
+	test.NewTempApp(t)
+	status := canvas.NewCircle(color.NRGBA{G: 0xff, A: 0xff})
+	status.Resize(fyne.NewSquareSize(16))
+	marker := &CustomMapMarker{Latitude: 55.9486, Longitude: -3.1999, Name: "Depot",
+		Object: container16(status), Anchor: fyne.NewPos(8, 8)}
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999), WithMapMarkers([]MapMarker{marker}))
+	w := test.NewTempWindow(t, m)
+	w.Resize(fyne.NewSize(400, 400))
+	m.Zoom(9)
+
+	obj := m.markerObjs[0]
+	assert.Equal(t, fyne.NewSquareSize(16), obj.item.Size())
+	// the middle of the circle is placed at the marker location
+	pos := m.PositionOf(55.9486, -3.1999)
+	assert.InDelta(t, pos.X, obj.Position().X+8, 0.01)
+	assert.InDelta(t, pos.Y, obj.Position().Y+8, 0.01)
+
+	test.Tap(obj.item) // shows the title above, keeping the circle in place
+	m.markers.Refresh()
+	assert.True(t, obj.title.Visible())
+	itemPos := obj.Position().Add(obj.container.Objects[1].Position()).Add(obj.item.Position())
+	assert.InDelta(t, pos.X, itemPos.X+8, 0.01)
+	assert.InDelta(t, pos.Y, itemPos.Y+8, 0.01)
+}
+
+func TestMapMarker_Icon(t *testing.T) {
+	marker := NewMapMarkerWithIcon(1, 2, "Home", theme.HomeIcon())
+	assert.Equal(t, 1.0, marker.Lat())
+	assert.Equal(t, 2.0, marker.Lon())
+	assert.Equal(t, "Home", marker.Title())
+	assert.Equal(t, fyne.NewPos(16, 32), marker.MarkerAnchor())
+	assert.Equal(t, fyne.NewSquareSize(32), marker.MarkerObject().MinSize())
+}
+
+func TestMapMarker_Popup(t *testing.T) {
+	test.NewTempApp(t)
+	card := widget.NewCard("Incident 42", "Reported 10:32", widget.NewLabel("Road blocked"))
+	marker := &CustomMapMarker{Latitude: 55.9486, Longitude: -3.1999, Name: "Incident", Popup: card}
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999), WithMapMarkers([]MapMarker{marker}))
+	w := test.NewTempWindow(t, m)
+	w.Resize(fyne.NewSize(400, 400))
+
+	var tapped MapMarker
+	m.OnMarkerTapped = func(mm MapMarker) {
+		tapped = mm
+	}
+	obj := m.markerObjs[0]
+	test.Tap(obj.item)
+	assert.Equal(t, marker, tapped)
+	assert.False(t, obj.title.Visible()) // the popup replaces the title
+	assert.NotNil(t, obj.popup)
+	assert.True(t, obj.popup.Visible())
+	assert.NotNil(t, w.Canvas().Overlays().Top())
+
+	// the popup sits above the default pin
+	popupBottom := obj.popup.Content.Position().Y + obj.popup.Content.Size().Height
+	assert.LessOrEqual(t, popupBottom, fyne.CurrentApp().Driver().AbsolutePositionForObject(obj.item).Y)
+}
+
+// container16 wraps an object so that it has a minimum size of 16x16.
+func TestMapMarker_Dragged(t *testing.T) {
+	test.NewTempApp(t)
+	var dragLat, dragLon, endLat, endLon float64
+	marker := &CustomMapMarker{Latitude: 55.9486, Longitude: -3.1999, Draggable: true,
+		OnDragged: func(lat, lon float64) { dragLat, dragLon = lat, lon },
+		OnDragEnd: func(lat, lon float64) { endLat, endLon = lat, lon }}
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999), WithMapMarkers([]MapMarker{marker}))
+	w := test.NewTempWindow(t, m)
+	w.Resize(fyne.NewSize(400, 400))
+	m.Zoom(9)
+
+	start := m.PositionOf(marker.Lat(), marker.Lon())
+	wantLat, wantLon := m.LatLonAt(start.AddXY(20, 10))
+	obj := m.markerObjs[0]
+	obj.item.Dragged(&fyne.DragEvent{Dragged: fyne.NewDelta(20, 10)})
+	assert.InDelta(t, wantLat, dragLat, 1e-9)
+	assert.InDelta(t, wantLon, dragLon, 1e-9)
+	assert.Equal(t, dragLat, marker.Latitude)
+	assert.Zero(t, endLat)
+	pin := obj.Position().Add(obj.pinOffset())
+	assert.InDelta(t, start.X+20, pin.X, 0.01)
+	assert.InDelta(t, start.Y+10, pin.Y, 0.01)
+
+	obj.item.DragEnd()
+	assert.Equal(t, dragLat, endLat)
+	assert.Equal(t, dragLon, endLon)
+
+	// a marker that is not draggable pans the map instead
+	marker.Draggable = false
+	lat, lon := marker.Lat(), marker.Lon()
+	obj.item.Dragged(&fyne.DragEvent{Dragged: fyne.NewDelta(20, 10)})
+	obj.item.DragEnd()
+	assert.Equal(t, lat, marker.Latitude)
+	assert.Equal(t, lon, marker.Longitude)
+	assert.InDelta(t, start.X+40, m.PositionOf(lat, lon).X, 0.01)
+}
+
+func TestMap_Editing(t *testing.T) {
+	test.NewTempApp(t)
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999))
+	w := test.NewTempWindow(t, m)
+	w.Resize(fyne.NewSize(400, 400))
+	m.Zoom(9)
+
+	var added MapMarker
+	m.OnMarkerAdded = func(marker MapMarker) { added = marker }
+	pos := fyne.NewPos(150, 220)
+	test.TapAt(m, pos)
+	assert.Nil(t, added)
+	assert.Empty(t, m.markerObjs)
+
+	m.SetEditing(true)
+	assert.True(t, m.Editing())
+	test.TapAt(m, pos)
+	assert.NotNil(t, added)
+	assert.Len(t, m.markerObjs, 1)
+	lat, lon := m.LatLonAt(pos)
+	assert.Equal(t, lat, added.Lat())
+	assert.Equal(t, lon, added.Lon())
+	assert.True(t, added.(MapMarkerDraggable).MarkerDraggable())
+}
+
+func container16(o fyne.CanvasObject) fyne.CanvasObject {
+	r := canvas.NewRectangle(color.Transparent)
+	r.SetMinSize(fyne.NewSquareSize(16))
+	return &fyne.Container{Layout: stackLayout{}, Objects: []fyne.CanvasObject{r, o}}
+}
+
+type stackLayout struct{}
+
+func (stackLayout) Layout(objs []fyne.CanvasObject, size fyne.Size) {
+	for _, o := range objs {
+		o.Resize(size)
+	}
+}
+
+func (stackLayout) MinSize(objs []fyne.CanvasObject) fyne.Size {
+	return objs[0].MinSize()
+}
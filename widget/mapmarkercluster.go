@@ -0,0 +1,138 @@
+package widget
+
+import (
+	"image"
+	"math"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// clusterRadius is the distance in canvas units within which markers are grouped when clustering is on.
+const clusterRadius = 64
+
+// mapMarkerCluster is a badge shown in place of a group of markers that are too close to show individually.
+type mapMarkerCluster struct {
+	widget.BaseWidget
+
+	lat, lon                       float64 // the middle of the grouped markers
+	minLat, minLon, maxLat, maxLon float64
+	count                          int
+	onTapped                       func(c *mapMarkerCluster)
+	sumLat, sumLon                 float64
+	background                     *canvas.Circle
+	label                          *canvas.Text
+}
+
+func newMapMarkerCluster(fn func(*mapMarkerCluster)) *mapMarkerCluster {
+	c := &mapMarkerCluster{onTapped: fn}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+func (c *mapMarkerCluster) CreateRenderer() fyne.WidgetRenderer {
+	c.background = canvas.NewCircle(theme.ColorForWidget(theme.ColorNamePrimary, c))
+	c.background.StrokeWidth = 2
+	c.label = canvas.NewText("", theme.ColorForWidget(theme.ColorNameForegroundOnPrimary, c))
+	c.label.TextStyle.Bold = true
+	c.label.Alignment = fyne.TextAlignCenter
+	c.update()
+
+	return widget.NewSimpleRenderer(container.NewStack(c.background, container.NewCenter(c.label)))
+}
+
+// MinSize grows the badge as the number of markers it contains increases.
+func (c *mapMarkerCluster) MinSize() fyne.Size {
+	size := float32(32 + 6*math.Floor(math.Log10(float64(c.count))))
+	return fyne.NewSquareSize(size)
+}
+
+func (c *mapMarkerCluster) Refresh() {
+	if c.label != nil {
+		c.update()
+	}
+	c.BaseWidget.Refresh()
+}
+
+func (c *mapMarkerCluster) Tapped(*fyne.PointEvent) {
+	if c.onTapped != nil {
+		c.onTapped(c)
+	}
+}
+
+func (c *mapMarkerCluster) update() {
+	c.background.FillColor = theme.ColorForWidget(theme.ColorNamePrimary, c)
+	c.background.StrokeColor = theme.ColorForWidget(theme.ColorNameForegroundOnPrimary, c)
+	c.label.Color = theme.ColorForWidget(theme.ColorNameForegroundOnPrimary, c)
+	c.label.Text = strconv.Itoa(c.count)
+}
+
+func (c *mapMarkerCluster) add(lat, lon float64) {
+	if c.count == 0 {
+		c.minLat, c.maxLat, c.minLon, c.maxLon = lat, lat, lon, lon
+	} else {
+		c.minLat, c.maxLat = math.Min(c.minLat, lat), math.Max(c.maxLat, lat)
+		c.minLon, c.maxLon = math.Min(c.minLon, lon), math.Max(c.maxLon, lon)
+	}
+	c.count++
+	c.sumLat += lat
+	c.sumLon += lon
+	c.lat, c.lon = c.sumLat/float64(c.count), c.sumLon/float64(c.count)
+}
+
+// clusterMarkers groups markers that are within clusterRadius of the first marker in a group at a zoom level,
+// where scale is the magnification of the tiles between zoom levels.
+// Markers that are alone are returned individually, the others are replaced by a cluster.
+func clusterMarkers(markers []*mapMarker, zoom int, scale float64, onTapped func(*mapMarkerCluster)) []fyne.CanvasObject {
+	type group struct {
+		x, y    float64 // world pixel position of the first marker
+		markers []*mapMarker
+	}
+
+	// groups are indexed by a grid of the cluster size so only neighbouring cells need to be checked
+	cells := make(map[image.Point][]*group)
+	var groups []*group
+	for _, marker := range markers {
+		x, y := latLonToTile(marker.obj.Lat(), marker.obj.Lon(), zoom)
+		x, y = x*tileSize*scale, y*tileSize*scale
+		cell := image.Pt(int(math.Floor(x/clusterRadius)), int(math.Floor(y/clusterRadius)))
+
+		var found *group
+	search:
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				for _, g := range cells[cell.Add(image.Pt(dx, dy))] {
+					if math.Hypot(g.x-x, g.y-y) <= clusterRadius {
+						found = g
+						break search
+					}
+				}
+			}
+		}
+		if found == nil {
+			found = &group{x: x, y: y}
+			cells[cell] = append(cells[cell], found)
+			groups = append(groups, found)
+		}
+		found.markers = append(found.markers, marker)
+	}
+
+	objs := make([]fyne.CanvasObject, 0, len(groups))
+	for _, g := range groups {
+		if len(g.markers) == 1 {
+			objs = append(objs, g.markers[0])
+			continue
+		}
+
+		cluster := newMapMarkerCluster(onTapped)
+		for _, marker := range g.markers {
+			cluster.add(marker.obj.Lat(), marker.obj.Lon())
+		}
+		objs = append(objs, cluster)
+	}
+	return objs
+}
@@ -0,0 +1,88 @@
+package widget
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap_MarkerClustering(t *testing.T) {
+	test.NewTempApp(t)
+	m := NewMapWithOptions(WithMarkerClustering(true), WithMapMarkers([]MapMarker{
+		NewMapMarker(51.50, -0.12, "London"),
+		NewMapMarker(51.51, -0.10, "City"),
+		NewMapMarker(48.85, 2.35, "Paris"),
+		NewMapMarker(-33.86, 151.21, "Sydney"),
+	}))
+	m.Resize(fyne.NewSize(512, 512))
+	m.Zoom(2)
+
+	// at a low zoom London, City and Paris are in the same cell
+	assert.Len(t, m.markers.Objects, 2)
+	cluster, ok := m.markers.Objects[0].(*mapMarkerCluster)
+	assert.True(t, ok)
+	assert.Equal(t, 3, cluster.count)
+	assert.Equal(t, 48.85, cluster.minLat)
+	assert.Equal(t, 2.35, cluster.maxLon)
+	_, ok = m.markers.Objects[1].(*mapMarker)
+	assert.True(t, ok)
+
+	m.Zoom(8) // Paris is now far enough from London to be shown alone
+	assert.Len(t, m.markers.Objects, 3)
+	cluster, ok = m.markers.Objects[0].(*mapMarkerCluster)
+	assert.True(t, ok)
+	assert.Equal(t, 2, cluster.count)
+
+	m.Zoom(14)
+	assert.Len(t, m.markers.Objects, 4)
+}
+
+func TestMap_MarkerClusteringZoomScale(t *testing.T) {
+	test.NewTempApp(t)
+	m := NewMapWithOptions(WithMarkerClustering(true), WithMapMarkers([]MapMarker{
+		NewMapMarker(51.50, -0.12, "London"),
+		NewMapMarker(48.85, 2.35, "Paris"),
+	}))
+	m.Resize(fyne.NewSize(512, 512))
+
+	// between levels the markers are grouped by their distance on screen, not at the nearest whole level
+	m.SetZoomLevel(4.4)
+	assert.Equal(t, 4, m.zoom)
+	assert.Len(t, m.markers.Objects, 2)
+	m.SetZoomLevel(3.6)
+	assert.Equal(t, 4, m.zoom)
+	assert.Len(t, m.markers.Objects, 1)
+}
+
+func TestMap_MarkerClusterTapped(t *testing.T) {
+	test.NewTempApp(t)
+	m := NewMapWithOptions(WithMarkerClustering(true), WithMapMarkers([]MapMarker{
+		NewMapMarker(51.50, -0.12, "London"),
+		NewMapMarker(51.51, -0.10, "City"),
+	}))
+	m.Resize(fyne.NewSize(512, 512))
+	m.Zoom(2)
+
+	cluster := m.markers.Objects[0].(*mapMarkerCluster)
+	test.Tap(cluster)
+	assert.Greater(t, m.zoom, 2)
+	assert.Len(t, m.markers.Objects, 2)
+	for _, o := range m.markers.Objects {
+		pos := m.getPosFromLatLon(o.(*mapMarker).obj.Lat(), o.(*mapMarker).obj.Lon())
+		assert.True(t, pos.X > 0 && pos.X < 512 && pos.Y > 0 && pos.Y < 512)
+	}
+}
+
+func TestMap_MarkerClusteringDisabled(t *testing.T) {
+	test.NewTempApp(t)
+	m := NewMapWithOptions(WithMapMarkers([]MapMarker{
+		NewMapMarker(51.50, -0.12, "London"),
+		NewMapMarker(51.51, -0.10, "City"),
+	}))
+	m.Zoom(2)
+
+	assert.Len(t, m.markers.Objects, 2)
+}
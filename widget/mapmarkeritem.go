@@ -3,14 +3,22 @@ package widget
 import (
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
 
 type mapMarkerItem struct {
 	widget.BaseWidget
+	obj fyne.CanvasObject // the marker content, if nil the default pin is shown
 	img *canvas.Image
 	fn  func()
+
+	focused bool // selected using the keyboard
+	focus   *canvas.Rectangle
+
+	onDragged func(*fyne.DragEvent)
+	onDragEnd func()
 }
 
 func newMapMarkerItem(fn func()) *mapMarkerItem {
@@ -26,8 +34,20 @@ func (m *mapMarkerItem) Tapped(ev *fyne.PointEvent) {
 	m.fn()
 }
 
+func (m *mapMarkerItem) Dragged(ev *fyne.DragEvent) {
+	if m.onDragged != nil {
+		m.onDragged(ev)
+	}
+}
+
+func (m *mapMarkerItem) DragEnd() {
+	if m.onDragEnd != nil {
+		m.onDragEnd()
+	}
+}
+
 func (m *mapMarkerItem) setup() {
-	if m.img != nil {
+	if m.img != nil || m.obj != nil {
 		return
 	}
 	res := theme.NewColoredResource(resourceMapmarkerSvg, theme.ColorNamePrimary)
@@ -37,5 +57,29 @@ func (m *mapMarkerItem) setup() {
 
 func (m *mapMarkerItem) CreateRenderer() fyne.WidgetRenderer {
 	m.setup()
-	return widget.NewSimpleRenderer(m.img)
+	m.focus = canvas.NewRectangle(theme.ColorForWidget(theme.ColorNameFocus, m))
+	m.focus.CornerRadius = theme.InputRadiusSize()
+	m.updateFocus()
+
+	content := fyne.CanvasObject(m.img)
+	if m.obj != nil {
+		content = m.obj
+	}
+	return widget.NewSimpleRenderer(container.NewStack(m.focus, content))
+}
+
+func (m *mapMarkerItem) Refresh() {
+	if m.focus != nil {
+		m.updateFocus()
+	}
+	m.BaseWidget.Refresh()
+}
+
+func (m *mapMarkerItem) updateFocus() {
+	m.focus.FillColor = theme.ColorForWidget(theme.ColorNameFocus, m)
+	if m.focused {
+		m.focus.Show()
+	} else {
+		m.focus.Hide()
+	}
 }
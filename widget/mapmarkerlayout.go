@@ -24,6 +24,13 @@ func (l *mapMarkerLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
 
 func (l *mapMarkerLayout) Layout(objects []fyne.CanvasObject, containerSize fyne.Size) {
 	for _, o := range objects {
+		if cluster, ok := o.(*mapMarkerCluster); ok {
+			size := cluster.MinSize()
+			cluster.Resize(size)
+			pos := l.getPosFromLatLon(cluster.lat, cluster.lon)
+			cluster.Move(pos.SubtractXY(size.Width/2, size.Height/2))
+			continue
+		}
 		marker, ok := o.(*mapMarker)
 		if !ok {
 			continue
@@ -0,0 +1,118 @@
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"sync"
+)
+
+// MBTilesProvider is a MapTileProvider that reads tiles from a local MBTiles file,
+// so that a map can be shown without a network connection.
+// See https://github.com/mapbox/mbtiles-spec for details of the format.
+type MBTilesProvider struct {
+	file *os.File
+	db   *sqliteFile
+
+	tiles, images *sqliteTable // images is only set for files that store tiles through a map table
+
+	metadata map[string]string
+	lock     sync.Mutex
+}
+
+// NewMBTilesProvider opens the MBTiles file at path for reading tiles.
+// The provider should be closed once it is no longer in use.
+// Files in SQLite's WAL journal mode are not supported, as only the main database file is read:
+// checkpoint the database, or switch it back to the rollback journal mode, before using it.
+func NewMBTilesProvider(path string) (*MBTilesProvider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := openMBTiles(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	p.file = f
+	return p, nil
+}
+
+func openMBTiles(r io.ReaderAt) (*MBTilesProvider, error) {
+	db, err := openSQLite(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &MBTilesProvider{db: db}
+	if tiles, ok := db.tables["tiles"]; ok {
+		p.tiles = tiles
+	} else if db.views["tiles"] {
+		p.tiles, p.images = db.tables["map"], db.tables["images"]
+	}
+	if p.tiles == nil || (db.views["tiles"] && p.images == nil) {
+		return nil, errors.New("mbtiles: file has no tiles table")
+	}
+	return p, nil
+}
+
+// Close releases the file used by this provider.
+func (p *MBTilesProvider) Close() error {
+	return p.file.Close()
+}
+
+// Metadata returns the values from the metadata table of the MBTiles file,
+// such as "name", "attribution", "minzoom" and "maxzoom".
+func (p *MBTilesProvider) Metadata() (map[string]string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	meta := make(map[string]string)
+	if table, ok := p.db.tables["metadata"]; ok {
+		err := table.each(func(_ int64, row []interface{}) bool {
+			name, _ := table.column(row, "name").(string)
+			meta[name] = fmt.Sprint(table.column(row, "value"))
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	p.metadata = meta
+	return meta, nil
+}
+
+// Tile returns the image for the tile at a zoom level and x, y tile position.
+func (p *MBTilesProvider) Tile(_ context.Context, zoom, x, y int) (image.Image, error) {
+	row := (1 << zoom) - 1 - y // MBTiles rows are numbered from the bottom, as in TMS
+	cols := []string{"zoom_level", "tile_column", "tile_row"}
+	values, ok, err := p.tiles.lookup(cols, []interface{}{int64(zoom), int64(x), int64(row)})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("mbtiles: no tile at %d/%d/%d", zoom, x, y)
+	}
+
+	table := p.tiles
+	if p.images != nil {
+		id := p.tiles.column(values, "tile_id")
+		values, ok, err = p.images.lookup([]string{"tile_id"}, []interface{}{id})
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("mbtiles: missing image for tile %d/%d/%d", zoom, x, y)
+		}
+		table = p.images
+	}
+
+	data, _ := table.column(values, "tile_data").([]byte)
+	return decodeTile(data)
+}
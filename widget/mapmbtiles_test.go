@@ -0,0 +1,91 @@
+package widget
+
+import (
+	"bytes"
+	"context"
+	"image/color"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMBTilesProvider_Tile(t *testing.T) {
+	p, err := NewMBTilesProvider("testdata/map/tiles.mbtiles")
+	assert.Nil(t, err)
+	defer p.Close()
+
+	img, err := p.Tile(context.Background(), 0, 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 32, img.Bounds().Dx()) // larger than a page, so read from overflow pages
+	assert.Equal(t, color.RGBA{A: 0xff}, color.RGBAModel.Convert(img.At(0, 0)))
+
+	for _, tile := range [][3]int{{1, 0, 1}, {3, 5, 2}, {4, 15, 0}, {4, 0, 15}, {4, 7, 9}} {
+		img, err = p.Tile(context.Background(), tile[0], tile[1], tile[2])
+		assert.Nil(t, err)
+		expected := color.RGBA{R: uint8(tile[0] * 40), G: uint8(tile[1] * 10), B: uint8(tile[2] * 10), A: 0xff}
+		assert.Equal(t, expected, color.RGBAModel.Convert(img.At(0, 0)))
+	}
+
+	_, err = p.Tile(context.Background(), 5, 0, 0)
+	assert.NotNil(t, err)
+}
+
+func TestMBTilesProvider_TileView(t *testing.T) {
+	p, err := NewMBTilesProvider("testdata/map/dedup.mbtiles")
+	assert.Nil(t, err)
+	defer p.Close()
+
+	img, err := p.Tile(context.Background(), 2, 3, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, color.RGBA{R: 80, G: 30, A: 0xff}, color.RGBAModel.Convert(img.At(0, 0)))
+}
+
+func TestMBTilesProvider_Metadata(t *testing.T) {
+	p, err := NewMBTilesProvider("testdata/map/tiles.mbtiles")
+	assert.Nil(t, err)
+	defer p.Close()
+
+	meta, err := p.Metadata()
+	assert.Nil(t, err)
+	assert.Equal(t, "Test", meta["name"])
+	assert.Equal(t, "Test data", meta["attribution"])
+
+	// the metadata table has no index, so this looks up by scanning
+	row, ok, err := p.db.tables["metadata"].lookup([]string{"name"}, []interface{}{"maxzoom"})
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "4", row[1])
+}
+
+func TestNewMBTilesProvider_Invalid(t *testing.T) {
+	_, err := NewMBTilesProvider("testdata/map/missing.mbtiles")
+	assert.NotNil(t, err)
+	_, err = NewMBTilesProvider("mapmbtiles.go")
+	assert.NotNil(t, err)
+}
+
+func FuzzMBTiles(f *testing.F) {
+	data, err := os.ReadFile("testdata/map/tiles.mbtiles")
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(uint(0), byte(0))
+	f.Add(uint(16), byte(0x7f))  // page size
+	f.Add(uint(103), byte(0x40)) // cell count of the schema page
+	f.Add(uint(512+8), byte(1))  // a cell pointer on the second page
+	f.Add(uint(len(data)-3), byte(0x80))
+
+	f.Fuzz(func(t *testing.T, offset uint, flip byte) {
+		corrupt := bytes.Clone(data)
+		corrupt[offset%uint(len(corrupt))] ^= flip
+		p, err := openMBTiles(bytes.NewReader(corrupt))
+		if err != nil {
+			return
+		}
+		_, _ = p.Metadata()
+		for _, tile := range [][3]int{{0, 0, 0}, {1, 0, 1}, {3, 5, 2}, {4, 7, 9}} {
+			_, _ = p.Tile(context.Background(), tile[0], tile[1], tile[2])
+		}
+	})
+}
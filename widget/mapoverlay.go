@@ -0,0 +1,330 @@
+package widget
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/srwiley/rasterx"
+	"github.com/twpayne/go-geom"
+	"golang.org/x/image/math/fixed"
+)
+
+// earthCircumference is the length of the equator in metres, as used by the web mercator projection.
+const earthCircumference = 40075016.686
+
+// MapOverlay is a shape drawn over the map tiles using geographic coordinates.
+// Overlays are drawn again whenever the map is moved or zoomed.
+type MapOverlay interface {
+	// Draw renders the overlay into an image covering the visible area of the map.
+	Draw(img draw.Image, proj MapProjection)
+}
+
+// MapProjection converts geographic coordinates into pixel positions when drawing a MapOverlay.
+type MapProjection interface {
+	// Pixel returns the position within the image of a latitude and longitude.
+	Pixel(lat, lon float64) (x, y float64)
+	// PixelsPerMetre returns how many image pixels represent one metre at a latitude.
+	PixelsPerMetre(lat float64) float64
+	// Scale returns the number of image pixels per canvas unit, for sizing strokes and symbols.
+	Scale() float32
+}
+
+// MapPolyline is a MapOverlay that draws a line through a path of points, such as a vehicle track.
+type MapPolyline struct {
+	Path        *geom.LineString // coordinates are longitude, latitude
+	StrokeColor color.Color
+	StrokeWidth float32
+}
+
+// NewMapPolyline returns a new line overlay that follows a path of longitude, latitude coordinates.
+func NewMapPolyline(path *geom.LineString, stroke color.Color, width float32) *MapPolyline {
+	return &MapPolyline{Path: path, StrokeColor: stroke, StrokeWidth: width}
+}
+
+// Draw renders this line into the provided image.
+func (l *MapPolyline) Draw(img draw.Image, proj MapProjection) {
+	if l.Path == nil || l.StrokeColor == nil || l.StrokeWidth <= 0 {
+		return
+	}
+
+	bounds := img.Bounds()
+	width := float64(l.StrokeWidth) * float64(proj.Scale())
+	points := projectCoords(proj, l.Path.FlatCoords(), l.Path.Stride())
+
+	scanner := rasterx.NewScannerGV(bounds.Dx(), bounds.Dy(), img, bounds)
+	dasher := rasterx.NewDasher(bounds.Dx(), bounds.Dy(), scanner)
+	dasher.SetColor(l.StrokeColor)
+	dasher.SetStroke(fixed.Int26_6(width*64), 0, rasterx.RoundCap, nil, rasterx.RoundGap, rasterx.Round, nil, 0)
+	clip := expandRect(bounds, width)
+	open := false
+	for i := 1; i < len(points); i++ {
+		a, b, ok := clipSegment(points[i-1], points[i], clip)
+		if !ok || (open && a != points[i-1]) {
+			if open {
+				dasher.Stop(false)
+				open = false
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		if !open {
+			dasher.Start(rasterx.ToFixedP(a[0], a[1]))
+			open = true
+		}
+		dasher.Line(rasterx.ToFixedP(b[0], b[1]))
+		if b != points[i] { // the line leaves the visible area
+			dasher.Stop(false)
+			open = false
+		}
+	}
+	if open {
+		dasher.Stop(false)
+	}
+	dasher.Draw()
+}
+
+// MapPolygon is a MapOverlay that draws an area, such as a geofence, with a fill and outline.
+type MapPolygon struct {
+	Shape       *geom.Polygon // coordinates are longitude, latitude, later rings are holes
+	FillColor   color.Color
+	StrokeColor color.Color
+	StrokeWidth float32
+}
+
+// NewMapPolygon returns a new area overlay for a polygon of longitude, latitude coordinates.
+func NewMapPolygon(shape *geom.Polygon, fill, stroke color.Color, width float32) *MapPolygon {
+	return &MapPolygon{Shape: shape, FillColor: fill, StrokeColor: stroke, StrokeWidth: width}
+}
+
+// Draw renders this polygon into the provided image.
+func (p *MapPolygon) Draw(img draw.Image, proj MapProjection) {
+	if p.Shape == nil {
+		return
+	}
+
+	bounds := img.Bounds()
+	width := float64(p.StrokeWidth) * float64(proj.Scale())
+	clip := expandRect(bounds, width+1)
+	var rings [][][2]float64
+	flat, stride, start := p.Shape.FlatCoords(), p.Shape.Stride(), 0
+	for i, end := range p.Shape.Ends() {
+		ring := clipRing(projectCoords(proj, flat[start:end], stride), clip)
+		start = end
+		if len(ring) <= 2 {
+			continue
+		}
+
+		// the fill uses non-zero winding, so holes must wind in the opposite direction to the outer ring
+		if (ringArea(ring) < 0) != (i > 0) {
+			for l, r := 0, len(ring)-1; l < r; l, r = l+1, r-1 {
+				ring[l], ring[r] = ring[r], ring[l]
+			}
+		}
+		rings = append(rings, ring)
+	}
+
+	drawArea(img, rings, p.FillColor, p.StrokeColor, width)
+}
+
+// MapCircle is a MapOverlay that draws a circle with a radius in metres around a location.
+type MapCircle struct {
+	Lat, Lon    float64
+	Radius      float64 // the radius in metres
+	FillColor   color.Color
+	StrokeColor color.Color
+	StrokeWidth float32
+}
+
+// NewMapCircle returns a new circle overlay with a radius in metres around the latitude and longitude.
+func NewMapCircle(lat, lon, radius float64, fill, stroke color.Color, width float32) *MapCircle {
+	return &MapCircle{Lat: lat, Lon: lon, Radius: radius, FillColor: fill, StrokeColor: stroke, StrokeWidth: width}
+}
+
+// Draw renders this circle into the provided image.
+func (c *MapCircle) Draw(img draw.Image, proj MapProjection) {
+	x, y := proj.Pixel(c.Lat, c.Lon)
+	r := c.Radius * proj.PixelsPerMetre(c.Lat)
+	width := float64(c.StrokeWidth) * float64(proj.Scale())
+	if !image.Rect(int(x-r-width), int(y-r-width), int(x+r+width)+1, int(y+r+width)+1).Overlaps(img.Bounds()) {
+		return
+	}
+
+	// approximate with enough segments that the edges are not visible
+	steps := int(math.Min(math.Max(r, 16), 1024))
+	ring := make([][2]float64, steps)
+	for i := range ring {
+		angle := float64(i) / float64(steps) * 2 * math.Pi
+		ring[i] = [2]float64{x + r*math.Cos(angle), y + r*math.Sin(angle)}
+	}
+	ring = clipRing(ring, expandRect(img.Bounds(), width+1))
+	if len(ring) > 2 {
+		drawArea(img, [][][2]float64{ring}, c.FillColor, c.StrokeColor, width)
+	}
+}
+
+type mapProjection struct {
+	m     *Map
+	scale float32 // image pixels per canvas unit
+}
+
+func (p *mapProjection) Pixel(lat, lon float64) (float64, float64) {
+	pos := p.m.PositionOf(lat, lon)
+	return float64(pos.X * p.scale), float64(pos.Y * p.scale)
+}
+
+func (p *mapProjection) PixelsPerMetre(lat float64) float64 {
+	worldSize := float64(tileSize) * math.Exp2(p.m.ZoomLevel()) * float64(p.scale)
+	return worldSize / (earthCircumference * math.Cos(lat*math.Pi/180))
+}
+
+func (p *mapProjection) Scale() float32 {
+	return p.scale
+}
+
+func drawArea(img draw.Image, rings [][][2]float64, fill, stroke color.Color, width float64) {
+	if len(rings) == 0 {
+		return
+	}
+
+	bounds := img.Bounds()
+	scanner := rasterx.NewScannerGV(bounds.Dx(), bounds.Dy(), img, bounds)
+	if fill != nil {
+		filler := rasterx.NewFiller(bounds.Dx(), bounds.Dy(), scanner)
+		filler.SetColor(fill)
+		for _, ring := range rings {
+			addRing(filler, ring)
+		}
+		filler.Draw()
+	}
+
+	if stroke != nil && width > 0 {
+		dasher := rasterx.NewDasher(bounds.Dx(), bounds.Dy(), scanner)
+		dasher.SetColor(stroke)
+		dasher.SetStroke(fixed.Int26_6(width*64), 0, nil, nil, nil, rasterx.Round, nil, 0)
+		for _, ring := range rings {
+			addRing(dasher, ring)
+		}
+		dasher.Draw()
+	}
+}
+
+func addRing(a rasterx.Adder, ring [][2]float64) {
+	for i, p := range ring {
+		if i == 0 {
+			a.Start(rasterx.ToFixedP(p[0], p[1]))
+		} else {
+			a.Line(rasterx.ToFixedP(p[0], p[1]))
+		}
+	}
+	a.Stop(true)
+}
+
+// ringArea returns the signed area of a ring, which is positive if it winds clockwise on screen.
+func ringArea(ring [][2]float64) float64 {
+	area := 0.0
+	prev := ring[len(ring)-1]
+	for _, p := range ring {
+		area += prev[0]*p[1] - p[0]*prev[1]
+		prev = p
+	}
+	return area / 2
+}
+
+func projectCoords(proj MapProjection, flat []float64, stride int) [][2]float64 {
+	if stride < 2 {
+		return nil
+	}
+	points := make([][2]float64, 0, len(flat)/stride)
+	for i := 0; i+1 < len(flat); i += stride {
+		x, y := proj.Pixel(flat[i+1], flat[i])
+		points = append(points, [2]float64{x, y})
+	}
+	return points
+}
+
+type clipBounds struct {
+	minX, minY, maxX, maxY float64
+}
+
+func expandRect(r image.Rectangle, margin float64) clipBounds {
+	return clipBounds{minX: float64(r.Min.X) - margin, minY: float64(r.Min.Y) - margin,
+		maxX: float64(r.Max.X) + margin, maxY: float64(r.Max.Y) + margin}
+}
+
+// clipSegment returns the part of the line from a to b that is inside the bounds,
+// using the Liang-Barsky algorithm. This avoids drawing with coordinates far outside the image.
+func clipSegment(a, b [2]float64, c clipBounds) ([2]float64, [2]float64, bool) {
+	t0, t1 := 0.0, 1.0
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	for _, edge := range [4][2]float64{{-dx, a[0] - c.minX}, {dx, c.maxX - a[0]}, {-dy, a[1] - c.minY}, {dy, c.maxY - a[1]}} {
+		p, q := edge[0], edge[1]
+		if p == 0 {
+			if q < 0 {
+				return a, b, false
+			}
+			continue
+		}
+		t := q / p
+		if p < 0 {
+			if t > t1 {
+				return a, b, false
+			} else if t > t0 {
+				t0 = t
+			}
+		} else {
+			if t < t0 {
+				return a, b, false
+			} else if t < t1 {
+				t1 = t
+			}
+		}
+	}
+
+	return [2]float64{a[0] + t0*dx, a[1] + t0*dy}, [2]float64{a[0] + t1*dx, a[1] + t1*dy}, true
+}
+
+// clipRing returns the part of a closed ring that is inside the bounds, using the Sutherland-Hodgman algorithm.
+func clipRing(ring [][2]float64, c clipBounds) [][2]float64 {
+	edges := []struct {
+		inside    func(p [2]float64) bool
+		intersect func(a, b [2]float64) [2]float64
+	}{
+		{func(p [2]float64) bool { return p[0] >= c.minX }, func(a, b [2]float64) [2]float64 {
+			return [2]float64{c.minX, a[1] + (b[1]-a[1])*(c.minX-a[0])/(b[0]-a[0])}
+		}},
+		{func(p [2]float64) bool { return p[0] <= c.maxX }, func(a, b [2]float64) [2]float64 {
+			return [2]float64{c.maxX, a[1] + (b[1]-a[1])*(c.maxX-a[0])/(b[0]-a[0])}
+		}},
+		{func(p [2]float64) bool { return p[1] >= c.minY }, func(a, b [2]float64) [2]float64 {
+			return [2]float64{a[0] + (b[0]-a[0])*(c.minY-a[1])/(b[1]-a[1]), c.minY}
+		}},
+		{func(p [2]float64) bool { return p[1] <= c.maxY }, func(a, b [2]float64) [2]float64 {
+			return [2]float64{a[0] + (b[0]-a[0])*(c.maxY-a[1])/(b[1]-a[1]), c.maxY}
+		}},
+	}
+
+	for _, edge := range edges {
+		if len(ring) == 0 {
+			break
+		}
+		in := ring
+		ring = make([][2]float64, 0, len(in)+4)
+		prev := in[len(in)-1]
+		for _, p := range in {
+			if edge.inside(p) {
+				if !edge.inside(prev) {
+					ring = append(ring, edge.intersect(prev, p))
+				}
+				ring = append(ring, p)
+			} else if edge.inside(prev) {
+				ring = append(ring, edge.intersect(prev, p))
+			}
+			prev = p
+		}
+	}
+	return ring
+}
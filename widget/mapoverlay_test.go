@@ -0,0 +1,95 @@
+package widget
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twpayne/go-geom"
+)
+
+// testProjection maps longitude to x and latitude to y, with one pixel per metre.
+type testProjection struct{}
+
+func (testProjection) Pixel(lat, lon float64) (float64, float64) {
+	return lon, lat
+}
+
+func (testProjection) PixelsPerMetre(float64) float64 {
+	return 1
+}
+
+func (testProjection) Scale() float32 {
+	return 1
+}
+
+func TestMapPolyline_Draw(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	path := geom.NewLineString(geom.XY).MustSetCoords([]geom.Coord{{10, 10}, {90, 10}, {90, 500}})
+	NewMapPolyline(path, color.RGBA{R: 0xff, A: 0xff}, 4).Draw(img, testProjection{})
+
+	assert.Equal(t, color.RGBA{R: 0xff, A: 0xff}, img.At(50, 10))
+	assert.Equal(t, color.RGBA{R: 0xff, A: 0xff}, img.At(90, 95))
+	assert.Equal(t, color.RGBA{}, img.At(50, 50))
+}
+
+func TestMapPolygon_Draw(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	shape := geom.NewPolygon(geom.XY).MustSetCoords([][]geom.Coord{
+		{{-1000, -1000}, {80, -1000}, {80, 80}, {-1000, 80}, {-1000, -1000}},
+		{{20, 20}, {40, 20}, {40, 40}, {20, 40}, {20, 20}}, // a hole
+	})
+	NewMapPolygon(shape, color.RGBA{B: 0xff, A: 0xff}, color.RGBA{R: 0xff, A: 0xff}, 2).Draw(img, testProjection{})
+
+	assert.Equal(t, color.RGBA{B: 0xff, A: 0xff}, img.At(10, 10))
+	assert.Equal(t, color.RGBA{}, img.At(30, 30))
+	assert.Equal(t, color.RGBA{R: 0xff, A: 0xff}, img.At(80, 50))
+	assert.Equal(t, color.RGBA{}, img.At(90, 90))
+}
+
+func TestMapCircle_Draw(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	NewMapCircle(50, 50, 20, color.RGBA{G: 0xff, A: 0xff}, nil, 0).Draw(img, testProjection{})
+
+	assert.Equal(t, color.RGBA{G: 0xff, A: 0xff}, img.At(50, 50))
+	assert.Equal(t, color.RGBA{G: 0xff, A: 0xff}, img.At(50, 68))
+	assert.Equal(t, color.RGBA{}, img.At(50, 72))
+	assert.Equal(t, color.RGBA{}, img.At(66, 66))
+}
+
+func TestMap_DrawOverlays(t *testing.T) {
+	test.NewTempApp(t)
+	m := NewMapWithOptions(WithMapOverlays([]MapOverlay{
+		NewMapCircle(0, 0, 1000000, color.RGBA{G: 0xff, A: 0xff}, nil, 0),
+	}))
+	m.Resize(fyne.NewSize(256, 256))
+	m.PanToLatLon(0, 0)
+
+	img := m.drawOverlays(256, 256)
+	center := m.getPosFromLatLon(0, 0)
+	assert.Equal(t, color.RGBA{G: 0xff, A: 0xff}, img.At(int(center.X), int(center.Y)))
+	// at zoom 0 the world is 256 pixels wide, so 1000km is around 6 pixels
+	assert.Equal(t, color.RGBA{G: 0xff, A: 0xff}, img.At(int(center.X)+5, int(center.Y)))
+	assert.Equal(t, color.RGBA{}, img.At(int(center.X)+8, int(center.Y)))
+
+	m.Zoom(2) // overlays follow the zoom level
+	img = m.drawOverlays(256, 256)
+	center = m.getPosFromLatLon(0, 0)
+	assert.Equal(t, color.RGBA{G: 0xff, A: 0xff}, img.At(int(center.X)+20, int(center.Y)))
+	assert.Equal(t, color.RGBA{}, img.At(int(center.X)+28, int(center.Y)))
+}
+
+func TestClipSegment(t *testing.T) {
+	c := clipBounds{maxX: 10, maxY: 10}
+	a, b, ok := clipSegment([2]float64{-10, 5}, [2]float64{20, 5}, c)
+	assert.True(t, ok)
+	assert.Equal(t, [2]float64{0, 5}, a)
+	assert.Equal(t, [2]float64{10, 5}, b)
+
+	_, _, ok = clipSegment([2]float64{-10, 20}, [2]float64{20, 20}, c)
+	assert.False(t, ok)
+}
@@ -0,0 +1,224 @@
+package widget
+
+import (
+	"math"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	overviewSize           = 128 // the default width and height of an overview, in canvas units
+	overviewZoomDifference = 4   // how many zoom levels the overview is further out than the map
+)
+
+// WithOverview enables or disables a small overview map in the corner of the map.
+// See MapOverview for details.
+func WithOverview(enable bool) MapOption {
+	return func(m *Map) {
+		m.showOverview = enable
+	}
+}
+
+// MapOverview is a small map showing the area around a Map at a lower zoom level, with the visible area outlined.
+// Dragging the outline pans the map, and tapping the overview moves the map to that location.
+// It draws the same tile layers as the map it follows, and changes to them are shown when the map is next refreshed.
+type MapOverview struct {
+	widget.BaseWidget
+
+	m      *Map // the map that this overview follows
+	inset  *Map
+	frame  *mapOverviewFrame
+	border *canvas.Rectangle
+
+	layers []*MapTileLayer // the map layers that the inset layers were made for
+	level  float64         // the zoom level of the map when the inset was last moved
+	lat    float64
+	lon    float64
+}
+
+// NewMapOverview returns an overview that follows the area shown by a map.
+func NewMapOverview(m *Map) *MapOverview {
+	o := &MapOverview{m: m}
+	o.ExtendBaseWidget(o)
+
+	o.inset = NewMapWithOptions(WithZoomButtons(false), WithScrollButtons(false), WithAttribution(false, "", ""))
+	o.frame = newMapOverviewFrame(o)
+	return o
+}
+
+func (o *MapOverview) CreateRenderer() fyne.WidgetRenderer {
+	// the map only moves overviews that are shown, and forgets them when their renderer is destroyed
+	if !slices.Contains(o.m.overviews, o) {
+		o.m.overviews = append(o.m.overviews, o)
+	}
+
+	o.border = canvas.NewRectangle(nil)
+	o.border.StrokeWidth = 1
+	o.border.StrokeColor = theme.ColorForWidget(theme.ColorNameShadow, o)
+	o.update()
+
+	content := container.NewStack(o.inset, o.frame, o.border)
+	return &mapOverviewRenderer{WidgetRenderer: widget.NewSimpleRenderer(content), o: o}
+}
+
+// MinSize returns the default size of an overview.
+func (o *MapOverview) MinSize() fyne.Size {
+	return fyne.NewSquareSize(overviewSize)
+}
+
+func (o *MapOverview) Refresh() {
+	if o.border != nil {
+		o.border.StrokeColor = theme.ColorForWidget(theme.ColorNameShadow, o)
+	}
+	o.update()
+	o.BaseWidget.Refresh()
+}
+
+func (o *MapOverview) Resize(s fyne.Size) {
+	o.BaseWidget.Resize(s)
+	o.level = -1 // centre the inset again for the new size
+	o.update()
+}
+
+// update moves the inset to the area around the map and outlines the part of it that the map shows.
+// While the outline is dragged the inset stays still, so that the outline follows the pointer.
+func (o *MapOverview) update() {
+	if o.m.Size().IsZero() || o.inset.Size().IsZero() {
+		return
+	}
+	o.updateLayers()
+
+	level := o.m.ZoomLevel()
+	size := o.m.Size()
+	lat, lon := o.m.LatLonAt(fyne.NewPos(size.Width/2, size.Height/2))
+	if !o.frame.dragging && (level != o.level || lat != o.lat || lon != o.lon) {
+		o.level, o.lat, o.lon = level, lat, lon
+		o.inset.setZoomLevel(math.Max(level-overviewZoomDifference, 0))
+		o.inset.PanToLatLon(lat, lon)
+	}
+
+	bounds := o.m.VisibleBounds()
+	topLeft := o.inset.PositionOf(bounds.MaxLat, bounds.MinLon)
+	bottomRight := o.inset.PositionOf(bounds.MinLat, bounds.MaxLon)
+	o.frame.setViewport(topLeft, fyne.NewSize(bottomRight.X-topLeft.X, bottomRight.Y-topLeft.Y))
+}
+
+// updateLayers makes inset layers for the tile layers of the map, so that the inset can load tiles for them separately.
+// A map without tile layers returns a new base layer when its tile source changes, so the inset follows it too.
+func (o *MapOverview) updateLayers() {
+	if layers := o.m.tileLayers(); !slices.Equal(o.layers, layers) {
+		o.layers = append([]*MapTileLayer(nil), layers...)
+		inset := make([]*MapTileLayer, len(o.layers))
+		for i, l := range o.layers {
+			inset[i] = &MapTileLayer{Provider: l.Provider, HiDPIProvider: l.HiDPIProvider, TileSize: l.TileSize}
+		}
+		o.inset.SetTileLayers(inset)
+	}
+
+	for i, l := range o.layers {
+		o.inset.layers[i].Opacity, o.inset.layers[i].Hidden = l.Opacity, l.Hidden
+	}
+}
+
+// pan moves the map by a distance across the overview.
+func (o *MapOverview) pan(dx, dy float32) {
+	scale := float32(math.Exp2(o.m.ZoomLevel() - o.inset.ZoomLevel()))
+	o.m.stopZoomAnimation()
+	o.m.panBy(rotateBy(-dx*scale, -dy*scale, -o.m.bearing)) // the overview is always north up
+	o.m.Refresh()
+}
+
+// panTo centres the map on the location at a position of the overview.
+func (o *MapOverview) panTo(pos fyne.Position) {
+	o.m.stopZoomAnimation()
+	o.m.PanToLatLon(o.inset.LatLonAt(pos))
+}
+
+type mapOverviewRenderer struct {
+	fyne.WidgetRenderer
+	o *MapOverview
+}
+
+// Destroy stops the map from updating an overview that is no longer shown.
+func (r *mapOverviewRenderer) Destroy() {
+	r.o.m.overviews = slices.DeleteFunc(r.o.m.overviews, func(o *MapOverview) bool {
+		return o == r.o
+	})
+	r.WidgetRenderer.Destroy()
+}
+
+// mapOverviewFrame outlines the area of the map within an overview, and handles the input for the overview.
+type mapOverviewFrame struct {
+	widget.BaseWidget
+
+	o        *MapOverview
+	viewport *canvas.Rectangle
+	dragging bool // a drag started within the viewport
+	dragEnd  bool // the last drag event finished
+}
+
+func newMapOverviewFrame(o *MapOverview) *mapOverviewFrame {
+	f := &mapOverviewFrame{o: o, dragEnd: true}
+	f.viewport = canvas.NewRectangle(nil)
+	f.viewport.StrokeWidth = 2
+	f.ExtendBaseWidget(f)
+	return f
+}
+
+func (f *mapOverviewFrame) CreateRenderer() fyne.WidgetRenderer {
+	f.update()
+	return widget.NewSimpleRenderer(container.NewWithoutLayout(f.viewport))
+}
+
+func (f *mapOverviewFrame) Refresh() {
+	f.update()
+	f.BaseWidget.Refresh()
+}
+
+// Dragged pans the map if the drag started on the viewport outline.
+func (f *mapOverviewFrame) Dragged(ev *fyne.DragEvent) {
+	if f.dragEnd {
+		start := ev.Position.SubtractXY(ev.Dragged.DX, ev.Dragged.DY)
+		f.dragging = f.contains(start)
+		f.dragEnd = false
+	}
+	if f.dragging {
+		f.o.pan(ev.Dragged.DX, ev.Dragged.DY)
+	}
+}
+
+func (f *mapOverviewFrame) DragEnd() {
+	f.dragging = false
+	f.dragEnd = true
+	f.o.update()
+}
+
+// Scrolled stops scroll events from zooming the overview.
+func (f *mapOverviewFrame) Scrolled(*fyne.ScrollEvent) {
+}
+
+// Tapped moves the map to the location tapped.
+func (f *mapOverviewFrame) Tapped(ev *fyne.PointEvent) {
+	f.o.panTo(ev.Position)
+}
+
+func (f *mapOverviewFrame) contains(pos fyne.Position) bool {
+	tl, size := f.viewport.Position(), f.viewport.Size()
+	return pos.X >= tl.X && pos.Y >= tl.Y && pos.X <= tl.X+size.Width && pos.Y <= tl.Y+size.Height
+}
+
+func (f *mapOverviewFrame) setViewport(pos fyne.Position, size fyne.Size) {
+	f.viewport.Move(pos)
+	f.viewport.Resize(size)
+	f.viewport.Refresh()
+}
+
+func (f *mapOverviewFrame) update() {
+	f.viewport.StrokeColor = theme.ColorForWidget(theme.ColorNamePrimary, f)
+	f.viewport.FillColor = theme.ColorForWidget(theme.ColorNameSelection, f)
+}
@@ -0,0 +1,93 @@
+package widget
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapOverview_Viewport(t *testing.T) {
+	test.NewTempApp(t)
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999), WithOverview(true))
+	w := test.NewTempWindow(t, m)
+	w.Resize(fyne.NewSize(400, 400))
+	m.Zoom(9)
+
+	o := m.overview
+	assert.Equal(t, fyne.NewSquareSize(overviewSize), o.Size())
+	assert.InDelta(t, 5, o.inset.ZoomLevel(), 0.001)
+
+	// the outline covers the map size at a sixteenth of the scale, in the middle of the overview
+	viewport := o.frame.viewport
+	assert.InDelta(t, m.Size().Width/16, viewport.Size().Width, 0.5)
+	assert.InDelta(t, m.Size().Height/16, viewport.Size().Height, 0.5)
+	assert.InDelta(t, overviewSize/2, viewport.Position().X+viewport.Size().Width/2, 0.5)
+	assert.InDelta(t, overviewSize/2, viewport.Position().Y+viewport.Size().Height/2, 0.5)
+
+	m.PanEast()
+	assert.InDelta(t, overviewSize/2, viewport.Position().X+viewport.Size().Width/2, 0.5)
+}
+
+func TestMapOverview_Dragged(t *testing.T) {
+	test.NewTempApp(t)
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999), WithOverview(true))
+	w := test.NewTempWindow(t, m)
+	w.Resize(fyne.NewSize(400, 400))
+	m.Zoom(9)
+
+	o := m.overview
+	middle := fyne.NewPos(overviewSize/2, overviewSize/2)
+	lat, lon := o.inset.LatLonAt(middle.AddXY(20, 10))
+
+	// a drag outside the outline does nothing
+	o.frame.Dragged(&fyne.DragEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(10, 10)},
+		Dragged: fyne.NewDelta(5, 5)})
+	o.frame.DragEnd()
+	centreLat, centreLon := m.LatLonAt(fyne.NewPos(m.Size().Width/2, m.Size().Height/2))
+	assert.InDelta(t, 55.9486, centreLat, 0.001)
+	assert.InDelta(t, -3.1999, centreLon, 0.001)
+
+	o.frame.Dragged(&fyne.DragEvent{PointEvent: fyne.PointEvent{Position: middle.AddXY(10, 5)},
+		Dragged: fyne.NewDelta(10, 5)})
+	o.frame.Dragged(&fyne.DragEvent{PointEvent: fyne.PointEvent{Position: middle.AddXY(20, 10)},
+		Dragged: fyne.NewDelta(10, 5)})
+	viewport := o.frame.viewport
+	assert.InDelta(t, middle.X+20, viewport.Position().X+viewport.Size().Width/2, 0.5)
+	o.frame.DragEnd()
+
+	centreLat, centreLon = m.LatLonAt(fyne.NewPos(m.Size().Width/2, m.Size().Height/2))
+	assert.InDelta(t, lat, centreLat, 0.01)
+	assert.InDelta(t, lon, centreLon, 0.01)
+	assert.InDelta(t, middle.X, viewport.Position().X+viewport.Size().Width/2, 0.5)
+
+	lat, lon = o.inset.LatLonAt(fyne.NewPos(30, 40))
+	test.TapAt(o.frame, fyne.NewPos(30, 40))
+	centreLat, centreLon = m.LatLonAt(fyne.NewPos(m.Size().Width/2, m.Size().Height/2))
+	assert.InDelta(t, lat, centreLat, 0.01)
+	assert.InDelta(t, lon, centreLon, 0.01)
+}
+
+func TestMapOverview_FollowsSource(t *testing.T) {
+	test.NewTempApp(t)
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999), WithOverview(true))
+	w := test.NewTempWindow(t, m)
+	w.Resize(fyne.NewSize(400, 400))
+
+	o := m.overview
+	assert.Equal(t, []*MapOverview{o}, m.overviews)
+	p := &testTileProvider{}
+	WithTileProvider(p)(m)
+	m.Refresh()
+	assert.Len(t, o.inset.layers, 1)
+	assert.Equal(t, p, o.inset.layers[0].Provider)
+
+	layer := NewMapTileLayer(p, "", "")
+	m.SetTileLayers([]*MapTileLayer{m.tileLayers()[0], layer})
+	assert.Len(t, o.inset.layers, 2)
+
+	test.WidgetRenderer(o).Destroy()
+	assert.Empty(t, m.overviews)
+}
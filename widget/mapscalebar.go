@@ -0,0 +1,174 @@
+package widget
+
+import (
+	"math"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// MapUnits selects the units of distance shown by the map scale bar.
+type MapUnits int
+
+const (
+	// MapUnitsMetric shows distances in metres and kilometres.
+	MapUnitsMetric MapUnits = iota
+	// MapUnitsImperial shows distances in feet and miles.
+	MapUnitsImperial
+)
+
+const (
+	scaleBarWidth = 100 // the longest the scale bar can be, in canvas units
+
+	metresPerFoot = 0.3048
+	feetPerMile   = 5280
+)
+
+// WithScaleBar enables or disables a scale bar, which shows the distance across the middle of the map.
+// The scale changes as the map is zoomed and as it moves north or south.
+func WithScaleBar(enable bool, units MapUnits) MapOption {
+	return func(m *Map) {
+		m.showScale = enable
+		m.scaleUnits = units
+	}
+}
+
+// mapScaleBar is a line of a round distance in map units, with its length written above.
+type mapScaleBar struct {
+	widget.BaseWidget
+
+	units         MapUnits
+	metresPerUnit float64 // the distance on the ground covered by a canvas unit
+
+	length float32 // the length of the bar in canvas units
+	label  string
+}
+
+func newMapScaleBar(units MapUnits) *mapScaleBar {
+	s := &mapScaleBar{units: units}
+	s.ExtendBaseWidget(s)
+	return s
+}
+
+// setScale updates the bar for the distance covered by a canvas unit.
+func (s *mapScaleBar) setScale(metresPerUnit float64) {
+	if metresPerUnit == s.metresPerUnit {
+		return
+	}
+
+	s.metresPerUnit = metresPerUnit
+	s.Refresh()
+}
+
+func (s *mapScaleBar) CreateRenderer() fyne.WidgetRenderer {
+	r := &mapScaleBarRenderer{bar: s,
+		bg: canvas.NewRectangle(theme.Color(theme.ColorNameShadow)), text: canvas.NewText("", nil),
+		line: canvas.NewRectangle(nil), left: canvas.NewRectangle(nil), right: canvas.NewRectangle(nil)}
+	r.text.TextSize = theme.CaptionTextSize()
+	r.Refresh()
+	return r
+}
+
+func (s *mapScaleBar) update() {
+	if s.metresPerUnit <= 0 {
+		s.length, s.label = 0, ""
+		return
+	}
+
+	metres, label := scaleDistance(s.metresPerUnit*scaleBarWidth, s.units)
+	s.length = float32(metres / s.metresPerUnit)
+	s.label = label
+}
+
+type mapScaleBarRenderer struct {
+	bar               *mapScaleBar
+	bg                *canvas.Rectangle
+	text              *canvas.Text
+	line, left, right *canvas.Rectangle
+}
+
+func (r *mapScaleBarRenderer) Destroy() {
+}
+
+func (r *mapScaleBarRenderer) Layout(size fyne.Size) {
+	pad := theme.Padding()
+	length := r.bar.length
+	r.bg.Resize(fyne.NewSize(length+pad*2, size.Height))
+
+	r.text.Move(fyne.NewPos(pad, 0))
+	r.text.Resize(r.text.MinSize())
+
+	y := size.Height - pad - 2
+	r.line.Move(fyne.NewPos(pad, y))
+	r.line.Resize(fyne.NewSize(length, 2))
+	r.left.Move(fyne.NewPos(pad, y-4))
+	r.left.Resize(fyne.NewSize(2, 6))
+	r.right.Move(fyne.NewPos(pad+length-2, y-4))
+	r.right.Resize(fyne.NewSize(2, 6))
+}
+
+func (r *mapScaleBarRenderer) MinSize() fyne.Size {
+	pad := theme.Padding()
+	return fyne.NewSize(scaleBarWidth+pad*2, r.text.MinSize().Height+pad+6)
+}
+
+func (r *mapScaleBarRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.bg, r.text, r.line, r.left, r.right}
+}
+
+func (r *mapScaleBarRenderer) Refresh() {
+	r.bar.update()
+	fg := theme.ColorForWidget(theme.ColorNameForeground, r.bar)
+	r.bg.FillColor = theme.ColorForWidget(theme.ColorNameShadow, r.bar)
+	r.text.Color = fg
+	r.text.Text = r.bar.label
+	r.line.FillColor, r.left.FillColor, r.right.FillColor = fg, fg, fg
+	r.Layout(r.bar.Size())
+
+	for _, o := range r.Objects() {
+		o.Refresh()
+	}
+}
+
+// scaleDistance returns the longest round distance that is no more than limit metres, along with its label.
+func scaleDistance(limit float64, units MapUnits) (float64, string) {
+	if units == MapUnitsImperial {
+		feet := limit / metresPerFoot
+		if feet < feetPerMile {
+			feet = roundDistance(feet)
+			return feet * metresPerFoot, formatDistance(feet) + " ft"
+		}
+
+		miles := roundDistance(feet / feetPerMile)
+		return miles * feetPerMile * metresPerFoot, formatDistance(miles) + " mi"
+	}
+
+	if limit < 1000 {
+		metres := roundDistance(limit)
+		return metres, formatDistance(metres) + " m"
+	}
+	km := roundDistance(limit / 1000)
+	return km * 1000, formatDistance(km) + " km"
+}
+
+// roundDistance returns the largest number no more than v that is 1, 2 or 5 multiplied by a power of 10.
+func roundDistance(v float64) float64 {
+	if v <= 0 {
+		return 0
+	}
+
+	power := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, step := range []float64{5, 2} {
+		if v >= step*power {
+			return step * power
+		}
+	}
+	return power
+}
+
+func formatDistance(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,55 @@
+package widget
+
+import (
+	"math"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaleDistance(t *testing.T) {
+	for _, tt := range []struct {
+		limit  float64
+		units  MapUnits
+		metres float64
+		label  string
+	}{
+		{29.9, MapUnitsMetric, 20, "20 m"},
+		{450, MapUnitsMetric, 200, "200 m"},
+		{1000, MapUnitsMetric, 1000, "1 km"},
+		{17120, MapUnitsMetric, 10000, "10 km"},
+		{3000, MapUnitsMetric, 2000, "2 km"},
+		{100, MapUnitsImperial, 200 * metresPerFoot, "200 ft"},
+		{1600, MapUnitsImperial, 5000 * metresPerFoot, "5000 ft"},
+		{17120, MapUnitsImperial, 10 * feetPerMile * metresPerFoot, "10 mi"},
+	} {
+		metres, label := scaleDistance(tt.limit, tt.units)
+		assert.InDelta(t, tt.metres, metres, 1e-9, tt.label)
+		assert.Equal(t, tt.label, label)
+	}
+}
+
+func TestMap_ScaleBar(t *testing.T) {
+	test.NewTempApp(t)
+	m := NewMapWithOptions(AtLatLon(55.9486, -3.1999), WithScaleBar(true, MapUnitsMetric))
+	w := test.NewTempWindow(t, m)
+	w.Resize(fyne.NewSize(400, 400))
+	m.Zoom(9)
+
+	lat, _ := m.LatLonAt(fyne.NewPos(m.Size().Width/2, m.Size().Height/2))
+	perUnit := earthCircumference * math.Cos(lat*math.Pi/180) / (tileSize * 512)
+	assert.Equal(t, "10 km", m.scaleBar.label)
+	assert.InDelta(t, 10000/perUnit, m.scaleBar.length, 0.01)
+
+	m.Zoom(11)
+	assert.Equal(t, "2 km", m.scaleBar.label)
+	assert.InDelta(t, 2000/(perUnit/4), m.scaleBar.length, 0.1)
+
+	// further south the same distance is shorter on the map
+	m.PanToLatLon(0, 0)
+	m.Refresh()
+	assert.Equal(t, "5 km", m.scaleBar.label)
+}
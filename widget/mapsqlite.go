@@ -0,0 +1,787 @@
+package widget
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+)
+
+// sqliteFile is a minimal read-only reader for the SQLite database file format.
+// It supports just enough to look up rows in tables, such as those in an MBTiles file,
+// without needing a database driver. See https://www.sqlite.org/fileformat2.html.
+// Only the main database file is read, so databases in WAL journal mode are not supported:
+// changes that are still in the write-ahead log, and have not been checkpointed, are not seen.
+type sqliteFile struct {
+	r        io.ReaderAt
+	pageSize int
+	usable   int
+
+	tables map[string]*sqliteTable
+	views  map[string]bool
+}
+
+type sqliteTable struct {
+	db       *sqliteFile
+	root     uint32
+	columns  []string
+	rowidCol int // the column that is an alias for the rowid, or -1
+
+	indexes []*sqliteIndex
+
+	scanLock sync.Mutex
+	scanned  map[string]map[string]int64 // rows by value, for lookups where there is no index
+}
+
+type sqliteIndex struct {
+	root    uint32
+	columns []string
+}
+
+const (
+	sqlitePageInteriorIndex = 2
+	sqlitePageInteriorTable = 5
+	sqlitePageLeafIndex     = 10
+	sqlitePageLeafTable     = 13
+)
+
+var errSQLiteCorrupt = errors.New("sqlite: malformed database file")
+
+func openSQLite(r io.ReaderAt) (*sqliteFile, error) {
+	header := make([]byte, 100)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+	if string(header[:16]) != "SQLite format 3\x00" {
+		return nil, errors.New("sqlite: not a database file")
+	}
+
+	db := &sqliteFile{r: r, tables: make(map[string]*sqliteTable), views: make(map[string]bool)}
+	db.pageSize = int(binary.BigEndian.Uint16(header[16:18]))
+	if db.pageSize == 1 {
+		db.pageSize = 65536
+	}
+	db.usable = db.pageSize - int(header[20])
+	if db.pageSize < 512 || db.pageSize&(db.pageSize-1) != 0 || db.usable < 480 {
+		return nil, errSQLiteCorrupt
+	}
+	if enc := binary.BigEndian.Uint32(header[56:60]); enc > 1 {
+		return nil, errors.New("sqlite: only UTF-8 databases are supported")
+	}
+
+	return db, db.readSchema()
+}
+
+// lookup returns the values of the first row in a table where the named columns have the provided values.
+// The returned values are in the order of the table columns.
+func (t *sqliteTable) lookup(columns []string, values []interface{}) ([]interface{}, bool, error) {
+	if idx, key := t.indexFor(columns, values); idx != nil {
+		entry, ok, err := t.db.searchIndex(idx.root, key)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		if len(entry) == 0 {
+			return nil, false, errSQLiteCorrupt
+		}
+		rowid, ok := entry[len(entry)-1].(int64)
+		if !ok {
+			return nil, false, errSQLiteCorrupt
+		}
+		return t.row(rowid)
+	}
+
+	t.scanLock.Lock()
+	defer t.scanLock.Unlock()
+	name := strings.Join(columns, ",")
+	rows, ok := t.scanned[name]
+	if !ok {
+		var err error
+		if rows, err = t.scanColumns(columns); err != nil {
+			return nil, false, err
+		}
+		if t.scanned == nil {
+			t.scanned = make(map[string]map[string]int64)
+		}
+		t.scanned[name] = rows
+	}
+
+	rowid, ok := rows[fmt.Sprintf("%#v", values)]
+	if !ok {
+		return nil, false, nil
+	}
+	return t.row(rowid)
+}
+
+// column returns the value of a named column from the values of a row in this table.
+func (t *sqliteTable) column(row []interface{}, name string) interface{} {
+	for i, col := range t.columns {
+		if col == name && i < len(row) {
+			return row[i]
+		}
+	}
+	return nil
+}
+
+// each calls fn for every row in the table, stopping if fn returns false.
+func (t *sqliteTable) each(fn func(rowid int64, row []interface{}) bool) error {
+	_, err := t.db.walkTable(t.root, 0, func(rowid int64, payload []byte) (bool, error) {
+		row, err := t.decodeRow(rowid, payload)
+		if err != nil {
+			return false, err
+		}
+		return fn(rowid, row), nil
+	})
+	return err
+}
+
+func (t *sqliteTable) decodeRow(rowid int64, payload []byte) ([]interface{}, error) {
+	row, err := decodeSQLiteRecord(payload)
+	if err != nil {
+		return nil, err
+	}
+	for len(row) < len(t.columns) { // columns added later are missing from older rows
+		row = append(row, nil)
+	}
+	if t.rowidCol >= 0 {
+		row[t.rowidCol] = rowid
+	}
+	return row, nil
+}
+
+// indexFor finds an index that starts with the named columns and builds a key for it.
+func (t *sqliteTable) indexFor(columns []string, values []interface{}) (*sqliteIndex, []interface{}) {
+	for _, idx := range t.indexes {
+		if len(idx.columns) < len(columns) {
+			continue
+		}
+
+		key := make([]interface{}, len(columns))
+		for i, name := range idx.columns[:len(columns)] {
+			found := false
+			for j, col := range columns {
+				if col == name {
+					key[i] = values[j]
+					found = true
+					break
+				}
+			}
+			if !found {
+				key = nil
+				break
+			}
+		}
+		if key != nil {
+			return idx, key
+		}
+	}
+	return nil, nil
+}
+
+func (t *sqliteTable) row(rowid int64) ([]interface{}, bool, error) {
+	payload, ok, err := t.db.searchTable(t.root, rowid)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	row, err := t.decodeRow(rowid, payload)
+	return row, err == nil, err
+}
+
+func (t *sqliteTable) scanColumns(columns []string) (map[string]int64, error) {
+	pos := make([]int, len(columns))
+	for i, name := range columns {
+		pos[i] = -1
+		for j, col := range t.columns {
+			if col == name {
+				pos[i] = j
+			}
+		}
+		if pos[i] == -1 {
+			return nil, fmt.Errorf("sqlite: no such column %q", name)
+		}
+	}
+
+	rows := make(map[string]int64)
+	err := t.each(func(rowid int64, row []interface{}) bool {
+		values := make([]interface{}, len(pos))
+		for i, p := range pos {
+			values[i] = row[p]
+		}
+		rows[fmt.Sprintf("%#v", values)] = rowid
+		return true
+	})
+	return rows, err
+}
+
+func (db *sqliteFile) readSchema() error {
+	schema := &sqliteTable{db: db, root: 1, columns: []string{"type", "name", "tbl_name", "rootpage", "sql"},
+		rowidCol: -1}
+
+	var indexes [][]interface{}
+	err := schema.each(func(_ int64, row []interface{}) bool {
+		kind, _ := row[0].(string)
+		name, _ := row[1].(string)
+		root, _ := row[3].(int64)
+		sql, _ := row[4].(string)
+		switch kind {
+		case "table":
+			cols, rowidCol := parseSQLiteColumns(sql)
+			db.tables[name] = &sqliteTable{db: db, root: uint32(root), columns: cols, rowidCol: rowidCol}
+		case "view":
+			db.views[name] = true
+		case "index":
+			indexes = append(indexes, row)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, row := range indexes {
+		tableName, _ := row[2].(string)
+		table, ok := db.tables[tableName]
+		if !ok {
+			continue
+		}
+		root, _ := row[3].(int64)
+		sql, _ := row[4].(string)
+		var cols []string
+		if sql == "" { // automatic index for a UNIQUE or PRIMARY KEY table constraint
+			tableSQL, _ := db.schemaSQL(schema, tableName)
+			cols = parseSQLiteKeyConstraint(tableSQL)
+		} else {
+			cols, _ = parseSQLiteColumns(sql)
+		}
+		if len(cols) > 0 {
+			table.indexes = append(table.indexes, &sqliteIndex{root: uint32(root), columns: cols})
+		}
+	}
+	return nil
+}
+
+func (db *sqliteFile) schemaSQL(schema *sqliteTable, table string) (string, error) {
+	sql := ""
+	err := schema.each(func(_ int64, row []interface{}) bool {
+		if row[0] == "table" && row[1] == table {
+			sql, _ = row[4].(string)
+			return false
+		}
+		return true
+	})
+	return sql, err
+}
+
+func (db *sqliteFile) page(n uint32) ([]byte, int, error) {
+	if n < 1 {
+		return nil, 0, errSQLiteCorrupt
+	}
+	data := make([]byte, db.pageSize)
+	if _, err := db.r.ReadAt(data, int64(n-1)*int64(db.pageSize)); err != nil {
+		return nil, 0, err
+	}
+
+	header := 0
+	if n == 1 {
+		header = 100 // the database header is at the start of page 1
+	}
+	return data, header, nil
+}
+
+// cells returns the offsets of each cell on a b-tree page and the rightmost child page, if it is an interior page.
+func (db *sqliteFile) cells(data []byte, header int) (kind byte, cells []int, right uint32, err error) {
+	if header+8 > len(data) {
+		return 0, nil, 0, errSQLiteCorrupt
+	}
+	kind = data[header]
+	count := int(binary.BigEndian.Uint16(data[header+3:]))
+	start := header + 8
+	if kind == sqlitePageInteriorIndex || kind == sqlitePageInteriorTable {
+		right = binary.BigEndian.Uint32(data[header+8:])
+		start += 4
+	} else if kind != sqlitePageLeafIndex && kind != sqlitePageLeafTable {
+		return 0, nil, 0, errSQLiteCorrupt
+	}
+	if start+count*2 > len(data) {
+		return 0, nil, 0, errSQLiteCorrupt
+	}
+
+	cells = make([]int, count)
+	for i := range cells {
+		cells[i] = int(binary.BigEndian.Uint16(data[start+i*2:]))
+		if cells[i] >= len(data) {
+			return 0, nil, 0, errSQLiteCorrupt
+		}
+	}
+	return kind, cells, right, nil
+}
+
+// payload reads a cell payload of the given size starting at off, following any overflow pages.
+func (db *sqliteFile) payload(data []byte, off int, size int64, index bool) ([]byte, error) {
+	u := int64(db.usable)
+	maxLocal := u - 35
+	if index {
+		maxLocal = (u-12)*64/255 - 23
+	}
+	local := size
+	if size > maxLocal {
+		minLocal := (u-12)*32/255 - 23
+		local = minLocal + (size-minLocal)%(u-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	if size < 0 || off+int(local) > len(data) {
+		return nil, errSQLiteCorrupt
+	}
+
+	out := make([]byte, 0, local) // the size is not trusted until the overflow pages have been read
+	out = append(out, data[off:off+int(local)]...)
+	if local == size {
+		return out, nil
+	}
+
+	next, err := readSQLitePageNumber(data, off+int(local))
+	if err != nil {
+		return nil, err
+	}
+	visited := make(map[uint32]bool)
+	for int64(len(out)) < size {
+		if next == 0 || visited[next] {
+			return nil, errSQLiteCorrupt
+		}
+		visited[next] = true
+		page, _, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+		next = binary.BigEndian.Uint32(page)
+		remain := size - int64(len(out))
+		if remain > u-4 {
+			remain = u - 4
+		}
+		out = append(out, page[4:4+remain]...)
+	}
+	return out, nil
+}
+
+func (db *sqliteFile) searchTable(root uint32, rowid int64) ([]byte, bool, error) {
+	page := root
+	for depth := 0; depth < 64; depth++ {
+		data, header, err := db.page(page)
+		if err != nil {
+			return nil, false, err
+		}
+		kind, cells, right, err := db.cells(data, header)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if kind == sqlitePageLeafTable {
+			for _, off := range cells {
+				size, n, err := readSQLiteCellVarint(data, off)
+				if err != nil {
+					return nil, false, err
+				}
+				id, m, err := readSQLiteCellVarint(data, off+n)
+				if err != nil {
+					return nil, false, err
+				}
+				if id == rowid {
+					payload, err := db.payload(data, off+n+m, size, false)
+					return payload, err == nil, err
+				}
+			}
+			return nil, false, nil
+		} else if kind != sqlitePageInteriorTable {
+			return nil, false, errSQLiteCorrupt
+		}
+
+		page = right
+		for _, off := range cells {
+			child, err := readSQLitePageNumber(data, off)
+			if err != nil {
+				return nil, false, err
+			}
+			key, _, err := readSQLiteCellVarint(data, off+4)
+			if err != nil {
+				return nil, false, err
+			}
+			if rowid <= key {
+				page = child
+				break
+			}
+		}
+	}
+	return nil, false, errSQLiteCorrupt
+}
+
+// searchIndex finds the first entry in an index that starts with the values in key.
+func (db *sqliteFile) searchIndex(root uint32, key []interface{}) ([]interface{}, bool, error) {
+	page := root
+	for depth := 0; depth < 64; depth++ {
+		data, header, err := db.page(page)
+		if err != nil {
+			return nil, false, err
+		}
+		kind, cells, right, err := db.cells(data, header)
+		if err != nil {
+			return nil, false, err
+		}
+
+		interior := kind == sqlitePageInteriorIndex
+		if !interior && kind != sqlitePageLeafIndex {
+			return nil, false, errSQLiteCorrupt
+		}
+
+		next := right
+		for _, off := range cells {
+			start := off
+			if interior {
+				start += 4
+			}
+			size, n, err := readSQLiteCellVarint(data, start)
+			if err != nil {
+				return nil, false, err
+			}
+			payload, err := db.payload(data, start+n, size, true)
+			if err != nil {
+				return nil, false, err
+			}
+			entry, err := decodeSQLiteRecord(payload)
+			if err != nil {
+				return nil, false, err
+			}
+
+			cmp := compareSQLiteKey(key, entry)
+			if cmp == 0 {
+				return entry, true, nil
+			} else if cmp < 0 {
+				if !interior {
+					return nil, false, nil
+				}
+				if next, err = readSQLitePageNumber(data, off); err != nil {
+					return nil, false, err
+				}
+				break
+			}
+		}
+
+		if !interior {
+			return nil, false, nil
+		}
+		page = next
+	}
+	return nil, false, errSQLiteCorrupt
+}
+
+// walkTable calls fn for each row in the b-tree below page, where depth is the number of interior pages above it.
+func (db *sqliteFile) walkTable(page uint32, depth int, fn func(int64, []byte) (bool, error)) (bool, error) {
+	if depth >= 64 {
+		return false, errSQLiteCorrupt
+	}
+	data, header, err := db.page(page)
+	if err != nil {
+		return false, err
+	}
+	kind, cells, right, err := db.cells(data, header)
+	if err != nil {
+		return false, err
+	}
+
+	switch kind {
+	case sqlitePageLeafTable:
+		for _, off := range cells {
+			size, n, err := readSQLiteCellVarint(data, off)
+			if err != nil {
+				return false, err
+			}
+			rowid, m, err := readSQLiteCellVarint(data, off+n)
+			if err != nil {
+				return false, err
+			}
+			payload, err := db.payload(data, off+n+m, size, false)
+			if err != nil {
+				return false, err
+			}
+			if more, err := fn(rowid, payload); !more || err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	case sqlitePageInteriorTable:
+		for _, off := range cells {
+			child, err := readSQLitePageNumber(data, off)
+			if err != nil {
+				return false, err
+			}
+			if more, err := db.walkTable(child, depth+1, fn); !more || err != nil {
+				return false, err
+			}
+		}
+		return db.walkTable(right, depth+1, fn)
+	}
+	return false, errSQLiteCorrupt
+}
+
+func compareSQLiteKey(key, entry []interface{}) int {
+	for i, k := range key {
+		if i >= len(entry) {
+			return 1
+		}
+		if c := compareSQLiteValue(k, entry[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareSQLiteValue orders values as SQLite does: NULL, then numbers, then text, then blobs.
+func compareSQLiteValue(a, b interface{}) int {
+	class := func(v interface{}) int {
+		switch v.(type) {
+		case nil:
+			return 0
+		case int64, float64:
+			return 1
+		case string:
+			return 2
+		}
+		return 3
+	}
+	if ca, cb := class(a), class(b); ca != cb {
+		return ca - cb
+	}
+
+	switch va := a.(type) {
+	case int64:
+		if vb, ok := b.(int64); ok {
+			switch {
+			case va < vb:
+				return -1
+			case va > vb:
+				return 1
+			}
+			return 0
+		}
+		return compareSQLiteFloat(float64(va), b.(float64))
+	case float64:
+		if vb, ok := b.(int64); ok {
+			return compareSQLiteFloat(va, float64(vb))
+		}
+		return compareSQLiteFloat(va, b.(float64))
+	case string:
+		return strings.Compare(va, b.(string))
+	case []byte:
+		return bytes.Compare(va, b.([]byte))
+	}
+	return 0
+}
+
+func compareSQLiteFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func decodeSQLiteRecord(data []byte) ([]interface{}, error) {
+	headerSize, n := readSQLiteVarint(data)
+	if n == 0 || headerSize < int64(n) || headerSize > int64(len(data)) {
+		return nil, errSQLiteCorrupt
+	}
+
+	var types []int64
+	for pos := n; pos < int(headerSize); {
+		t, n := readSQLiteVarint(data[pos:])
+		if n == 0 || t < 0 {
+			return nil, errSQLiteCorrupt
+		}
+		types = append(types, t)
+		pos += n
+	}
+
+	values := make([]interface{}, len(types))
+	body := data[headerSize:]
+	for i, t := range types {
+		size := sqliteSerialSize(t)
+		if size > len(body) {
+			return nil, errSQLiteCorrupt
+		}
+		field := body[:size]
+		body = body[size:]
+
+		switch {
+		case t == 0:
+			values[i] = nil
+		case t <= 6:
+			v := int64(int8(field[0])) // sign extend from the first byte
+			for _, b := range field[1:] {
+				v = v<<8 | int64(b)
+			}
+			values[i] = v
+		case t == 7:
+			values[i] = math.Float64frombits(binary.BigEndian.Uint64(field))
+		case t == 8:
+			values[i] = int64(0)
+		case t == 9:
+			values[i] = int64(1)
+		case t >= 12 && t%2 == 0:
+			values[i] = field
+		case t >= 13:
+			values[i] = string(field)
+		default:
+			return nil, errSQLiteCorrupt
+		}
+	}
+	return values, nil
+}
+
+func sqliteSerialSize(t int64) int {
+	switch {
+	case t >= 12:
+		return int((t - 12) / 2)
+	case t == 5:
+		return 6
+	case t == 6 || t == 7:
+		return 8
+	case t >= 1 && t <= 4:
+		return int(t)
+	}
+	return 0
+}
+
+// readSQLiteCellVarint decodes a varint at an offset in a page, returning errSQLiteCorrupt if it runs past the page.
+func readSQLiteCellVarint(data []byte, off int) (int64, int, error) {
+	if off >= len(data) {
+		return 0, 0, errSQLiteCorrupt
+	}
+	v, n := readSQLiteVarint(data[off:])
+	if n == 0 {
+		return 0, 0, errSQLiteCorrupt
+	}
+	return v, n, nil
+}
+
+// readSQLitePageNumber reads the 4 byte page number at an offset in a page.
+func readSQLitePageNumber(data []byte, off int) (uint32, error) {
+	if off+4 > len(data) {
+		return 0, errSQLiteCorrupt
+	}
+	return binary.BigEndian.Uint32(data[off:]), nil
+}
+
+// readSQLiteVarint decodes a big-endian variable length integer, returning the value and number of bytes read.
+func readSQLiteVarint(data []byte) (int64, int) {
+	var v uint64
+	for i := 0; i < 9 && i < len(data); i++ {
+		if i == 8 {
+			return int64(v<<8 | uint64(data[i])), 9
+		}
+		v = v<<7 | uint64(data[i]&0x7f)
+		if data[i]&0x80 == 0 {
+			return int64(v), i + 1
+		}
+	}
+	return 0, 0
+}
+
+// parseSQLiteColumns returns the column names from a CREATE TABLE or CREATE INDEX statement,
+// and the position of a column that is an alias for the rowid, or -1 if there is none.
+func parseSQLiteColumns(sql string) ([]string, int) {
+	defs := splitSQLiteDefinitions(sql)
+	rowid := -1
+	var cols []string
+	for _, def := range defs {
+		fields := strings.Fields(def)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "CONSTRAINT", "PRIMARY", "UNIQUE", "CHECK", "FOREIGN":
+			continue
+		}
+
+		upper := strings.ToUpper(strings.Join(fields[1:], " "))
+		if strings.HasPrefix(upper, "INTEGER PRIMARY KEY") && !strings.Contains(upper, "DESC") {
+			rowid = len(cols)
+		}
+		cols = append(cols, unquoteSQLiteName(fields[0]))
+	}
+	return cols, rowid
+}
+
+// parseSQLiteKeyConstraint returns the columns of the UNIQUE or PRIMARY KEY constraint in a CREATE TABLE statement.
+// If there is more than one such constraint nil is returned, as we cannot tell which index is for which.
+func parseSQLiteKeyConstraint(sql string) []string {
+	var keys [][]string
+	for _, def := range splitSQLiteDefinitions(sql) {
+		fields := strings.Fields(strings.ToUpper(def))
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] != "UNIQUE" && fields[0] != "PRIMARY" && fields[0] != "CONSTRAINT" {
+			upper := strings.Join(fields, " ")
+			if strings.Contains(upper, " UNIQUE") ||
+				(strings.Contains(upper, " PRIMARY KEY") && !strings.HasPrefix(upper, fields[0]+" INTEGER PRIMARY KEY")) {
+				keys = append(keys, []string{unquoteSQLiteName(strings.Fields(def)[0])})
+			}
+			continue
+		}
+		if upper := strings.Join(fields, " "); !strings.Contains(upper, "UNIQUE") && !strings.Contains(upper, "PRIMARY") {
+			continue
+		}
+
+		start, end := strings.Index(def, "("), strings.LastIndex(def, ")")
+		if start < 0 || end < start {
+			continue
+		}
+		var cols []string
+		for _, col := range strings.Split(def[start+1:end], ",") {
+			if fields := strings.Fields(col); len(fields) > 0 {
+				cols = append(cols, unquoteSQLiteName(fields[0]))
+			}
+		}
+		keys = append(keys, cols)
+	}
+
+	if len(keys) != 1 {
+		return nil
+	}
+	return keys[0]
+}
+
+// splitSQLiteDefinitions returns the comma separated items inside the outer brackets of a statement.
+func splitSQLiteDefinitions(sql string) []string {
+	start, end := strings.Index(sql, "("), strings.LastIndex(sql, ")")
+	if start < 0 || end < start {
+		return nil
+	}
+
+	var defs []string
+	depth, last := 0, start+1
+	for i := start + 1; i < end; i++ {
+		switch sql[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				defs = append(defs, sql[last:i])
+				last = i + 1
+			}
+		}
+	}
+	return append(defs, sql[last:end])
+}
+
+func unquoteSQLiteName(name string) string {
+	return strings.Trim(name, "\"`[]'")
+}
@@ -0,0 +1,262 @@
+package widget
+
+import (
+	"image"
+	"image/color"
+
+	"fyne.io/fyne/v2/theme"
+
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/math/fixed"
+)
+
+// MapTileLayer is a set of tiles drawn by a Map, such as a base map or a transparent weather overlay.
+// Layers are drawn in order, so later layers appear above earlier ones.
+// After changing the fields of a layer that is shown call Refresh on the Map.
+type MapTileLayer struct {
+	// Provider supplies the tile images for this layer.
+	Provider MapTileProvider
+	// HiDPIProvider, if set, is used instead of Provider on high density screens.
+	HiDPIProvider MapTileProvider
+	// TileSize is the width in pixels of the tiles from the provider, if not 256. See WithTileSize.
+	TileSize int
+
+	// Opacity is how solid the layer is drawn, from 0 (invisible) to 1.
+	Opacity float32
+	// Hidden stops the layer from being drawn or included in the attribution.
+	Hidden bool
+
+	// AttributionLabel is the credit shown for the tile data, if any.
+	AttributionLabel string
+	// AttributionURL is the link opened when the attribution is tapped.
+	AttributionURL string
+
+	loader      *tileLoader
+	loaderHiDPI bool // the loader is using the HiDPIProvider
+	scaled      map[MapTileKey]*scaledTile
+}
+
+// NewMapTileLayer returns a new fully opaque tile layer using the tile provider and attribution.
+func NewMapTileLayer(p MapTileProvider, attributionLabel, attributionURL string) *MapTileLayer {
+	return &MapTileLayer{Provider: p, Opacity: 1, AttributionLabel: attributionLabel, AttributionURL: attributionURL}
+}
+
+// WithTileLayers configures the map to draw a stack of tile layers.
+// This replaces the single tile source set using WithOsmTiles, WithTileSource or WithTileProvider.
+func WithTileLayers(layers ...*MapTileLayer) MapOption {
+	return func(m *Map) {
+		m.SetTileLayers(layers)
+	}
+}
+
+// SetTileLayers updates the stack of tile layers that the map draws.
+// If the list is empty the map returns to using its tile source.
+func (m *Map) SetTileLayers(layers []*MapTileLayer) {
+	for _, old := range m.layers {
+		if old.loader != nil && !containsLayer(layers, old) {
+			old.loader.retain(nil)
+		}
+	}
+
+	m.layers = layers
+	m.Refresh()
+}
+
+// tileLayers returns the layers to draw, which is a single layer using the map tile source if none have been set.
+func (m *Map) tileLayers() []*MapTileLayer {
+	if len(m.layers) > 0 {
+		return m.layers
+	}
+
+	if m.base == nil {
+		m.base = &MapTileLayer{Provider: m.provider, Opacity: 1, TileSize: m.sourceTileSize}
+		if m.provider == nil {
+			m.base.Provider = &httpTileProvider{source: m.tileSource, client: m.cl, cache: m.cache}
+			if m.hiDPITileSource != "" {
+				m.base.HiDPIProvider = &httpTileProvider{source: m.hiDPITileSource, client: m.cl, cache: m.cache}
+			}
+		}
+	}
+	return []*MapTileLayer{m.base}
+}
+
+// resetBaseLayer is called when the tile source options change so the next draw uses the new source.
+func (m *Map) resetBaseLayer() {
+	if m.base != nil && m.base.loader != nil {
+		m.base.loader.retain(nil)
+	}
+	m.base = nil
+}
+
+func (l *MapTileLayer) visible() bool {
+	return !l.Hidden && l.Opacity > 0 && l.Provider != nil
+}
+
+// prepare makes sure the layer has a tile loader for the current screen density.
+func (l *MapTileLayer) prepare(hiDPI bool, onLoad func()) {
+	hiDPI = hiDPI && l.HiDPIProvider != nil
+	if l.loader != nil && l.loaderHiDPI == hiDPI {
+		return
+	}
+
+	if l.loader != nil {
+		l.loader.retain(nil)
+	}
+	p := l.Provider
+	if hiDPI {
+		p = l.HiDPIProvider
+	}
+	l.loader = newTileLoader(p, onLoad)
+	l.loaderHiDPI = hiDPI
+	l.scaled = nil
+}
+
+// drawTile draws the tile from the standard 256 pixel grid into the bounds of the image, requesting it if needed.
+// When fast is set the image is scaled with a lower quality, for use while zooming.
+// If placeholder is set a missing tile is replaced by a placeholder colour if no lower zoom tile is available.
+func (l *MapTileLayer) drawTile(dst draw.Image, key MapTileKey, bounds image.Rectangle, fast, placeholder bool) {
+	src, part := l.loadedTile(key)
+	if src == nil {
+		if _, failed := l.loader.tile(l.sourceTileKey(key)); failed {
+			drawErrorTile(dst, bounds, placeholder)
+			return
+		}
+		l.loader.request(l.sourceTileKey(key))
+		l.drawMissingTile(dst, key, bounds, placeholder)
+		return
+	}
+
+	mask := l.mask()
+	size := bounds.Size()
+	if part.Size() == size {
+		draw.DrawMask(dst, bounds, src, part.Min, mask, image.Point{}, draw.Over)
+	} else if fast {
+		draw.ApproxBiLinear.Scale(dst, bounds, src, part, draw.Over, &draw.Options{SrcMask: mask})
+	} else {
+		draw.DrawMask(dst, bounds, l.scaledTile(key, src, part, size), image.Point{}, mask, image.Point{}, draw.Over)
+	}
+}
+
+// drawMissingTile fills the space for a tile that is still loading.
+// If a tile from a lower zoom level is available it is scaled up, otherwise a placeholder may be drawn.
+func (l *MapTileLayer) drawMissingTile(dst draw.Image, key MapTileKey, bounds image.Rectangle, placeholder bool) {
+	for z := 1; z <= 4 && key.Zoom-z >= 0; z++ {
+		parent := MapTileKey{Zoom: key.Zoom - z, X: key.X >> z, Y: key.Y >> z}
+		src, part := l.loadedTile(parent)
+		if src == nil {
+			continue
+		}
+
+		draw.ApproxBiLinear.Scale(dst, bounds, src, tilePart(part, parent.Zoom, key), draw.Over,
+			&draw.Options{SrcMask: l.mask()})
+		return
+	}
+
+	if placeholder {
+		draw.Draw(dst, bounds, image.NewUniform(theme.Color(theme.ColorNameInputBackground)), image.Point{}, draw.Over)
+	}
+}
+
+// drawErrorTile marks a tile that could not be loaded with a cross, on a placeholder background if requested.
+func drawErrorTile(dst draw.Image, bounds image.Rectangle, background bool) {
+	if background {
+		draw.Draw(dst, bounds, image.NewUniform(theme.Color(theme.ColorNameInputBackground)), image.Point{}, draw.Over)
+	}
+
+	b := dst.Bounds()
+	scanner := rasterx.NewScannerGV(b.Dx(), b.Dy(), dst, b)
+	dasher := rasterx.NewDasher(b.Dx(), b.Dy(), scanner)
+	dasher.SetColor(theme.Color(theme.ColorNameError))
+	width := float64(bounds.Dx()) / 64
+	dasher.SetStroke(fixed.Int26_6(width*64), 0, rasterx.RoundCap, nil, nil, rasterx.Round, nil, 0)
+
+	mid, size := bounds.Min.Add(bounds.Size().Div(2)), float64(bounds.Dx())/16
+	x, y := float64(mid.X), float64(mid.Y)
+	dasher.Start(rasterx.ToFixedP(x-size, y-size))
+	dasher.Line(rasterx.ToFixedP(x+size, y+size))
+	dasher.Stop(false)
+	dasher.Start(rasterx.ToFixedP(x+size, y-size))
+	dasher.Line(rasterx.ToFixedP(x-size, y+size))
+	dasher.Stop(false)
+	dasher.Draw()
+}
+
+// mask returns the mask to apply the layer opacity when drawing, or nil if it is opaque.
+func (l *MapTileLayer) mask() image.Image {
+	if l.Opacity >= 1 {
+		return nil
+	}
+	return image.NewUniform(color.Alpha16{A: uint16(l.Opacity * 0xffff)})
+}
+
+// retain cancels any requests for tiles that are not wanted, and forgets scaled tiles that were not drawn.
+func (l *MapTileLayer) retain(keys map[MapTileKey]bool) {
+	if l.loader == nil {
+		return
+	}
+
+	wanted := make(map[MapTileKey]bool, len(keys))
+	for key := range keys {
+		wanted[l.sourceTileKey(key)] = true
+	}
+	l.loader.retain(wanted)
+	for key := range l.scaled {
+		if !keys[key] {
+			delete(l.scaled, key)
+		}
+	}
+}
+
+// scaledTile keeps a copy of a tile image resized to the pixel size it is drawn at.
+type scaledTile struct {
+	src image.Image
+	img *image.NRGBA
+}
+
+// scaledTile returns the part of a tile image resized, re-using the last result if it still matches.
+func (l *MapTileLayer) scaledTile(key MapTileKey, src image.Image, part image.Rectangle, size image.Point) image.Image {
+	if cached, ok := l.scaled[key]; ok && cached.src == src && cached.img.Bounds().Size() == size {
+		return cached.img
+	}
+
+	img := image.NewNRGBA(image.Rectangle{Max: size})
+	draw.CatmullRom.Scale(img, img.Bounds(), src, part, draw.Src, nil)
+	if l.scaled == nil {
+		l.scaled = make(map[MapTileKey]*scaledTile)
+	}
+	l.scaled[key] = &scaledTile{src: src, img: img}
+	return img
+}
+
+// sourceTileKey returns the key of the tile from the provider that covers a tile of the standard 256 pixel grid.
+func (l *MapTileLayer) sourceTileKey(key MapTileKey) MapTileKey {
+	levels := 0
+	for size := l.TileSize; size > tileSize; size >>= 1 {
+		levels++
+	}
+	if levels > key.Zoom {
+		levels = key.Zoom
+	}
+	return MapTileKey{Zoom: key.Zoom - levels, X: key.X >> levels, Y: key.Y >> levels}
+}
+
+// loadedTile returns the image containing a tile of the standard grid, if it is loaded,
+// along with the part of the image that covers the tile.
+func (l *MapTileLayer) loadedTile(key MapTileKey) (image.Image, image.Rectangle) {
+	source := l.sourceTileKey(key)
+	src, _ := l.loader.tile(source)
+	if src == nil {
+		return nil, image.Rectangle{}
+	}
+	return src, tilePart(src.Bounds(), source.Zoom, key)
+}
+
+func containsLayer(layers []*MapTileLayer, l *MapTileLayer) bool {
+	for _, layer := range layers {
+		if layer == l {
+			return true
+		}
+	}
+	return false
+}
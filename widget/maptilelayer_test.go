@@ -0,0 +1,75 @@
+package widget
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testColorTileProvider returns tiles of a single colour.
+type testColorTileProvider struct {
+	color color.Color
+}
+
+func (p *testColorTileProvider) Tile(context.Context, int, int, int) (image.Image, error) {
+	return testTileImage(tileSize, p.color), nil
+}
+
+func TestMap_DrawTileLayers(t *testing.T) {
+	test.NewTempApp(t)
+	base := NewMapTileLayer(&testColorTileProvider{color: color.NRGBA{R: 0xff, A: 0xff}}, "", "")
+	overlay := NewMapTileLayer(&testColorTileProvider{color: color.NRGBA{B: 0xff, A: 0xff}}, "", "")
+	overlay.Opacity = 0.5
+	m := NewMapWithOptions(WithTileLayers(base, overlay))
+	m.Resize(fyne.NewSize(256, 256))
+	m.Zoom(1)
+
+	m.draw(256, 256)
+	waitForTile(t, base.loader, MapTileKey{Zoom: 1})
+	waitForTile(t, overlay.loader, MapTileKey{Zoom: 1})
+	img := m.draw(256, 256)
+	r, _, b, _ := img.At(128, 128).RGBA()
+	assert.InDelta(t, 0x7fff, r, 0x200)
+	assert.InDelta(t, 0x7fff, b, 0x200)
+
+	overlay.Hidden = true
+	m.Refresh()
+	img = m.draw(256, 256)
+	assert.Equal(t, color.NRGBA{R: 0xff, A: 0xff}, img.At(128, 128))
+}
+
+func TestMap_TileLayerAttribution(t *testing.T) {
+	base := NewMapTileLayer(&testColorTileProvider{}, "OpenStreetMap", "https://openstreetmap.org")
+	weather := NewMapTileLayer(&testColorTileProvider{}, "Weather", "https://example.com")
+	hills := NewMapTileLayer(&testColorTileProvider{}, "", "")
+	m := NewMapWithOptions(WithTileLayers(base, weather, hills))
+	w := test.NewTempWindow(t, m)
+	w.Resize(fyne.NewSize(400, 400))
+
+	assert.Equal(t, []string{"OpenStreetMap", "Weather"}, attributionLabels(m))
+
+	weather.Hidden = true
+	m.Refresh()
+	assert.Equal(t, []string{"OpenStreetMap"}, attributionLabels(m))
+
+	m.SetTileLayers(nil) // back to the default tile source
+	assert.Equal(t, []string{"OpenStreetMap"}, attributionLabels(m))
+	assert.Equal(t, "https://openstreetmap.org", m.attribution.Objects[1].(*widget.Hyperlink).URL.String())
+}
+
+func attributionLabels(m *Map) []string {
+	var labels []string
+	for _, o := range m.attribution.Objects {
+		if link, ok := o.(*widget.Hyperlink); ok {
+			labels = append(labels, link.Text)
+		}
+	}
+	return labels
+}
@@ -0,0 +1,139 @@
+package widget
+
+import (
+	"context"
+	"errors"
+	"image"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+const (
+	// tileLoaderWorkers is the maximum number of tiles that will be requested at the same time.
+	tileLoaderWorkers = 4
+	// tileRetryDelay is how long to wait before requesting a tile that failed to load again.
+	tileRetryDelay = 30 * time.Second
+	// tileLoaderScreens is the number of screens of tiles each loader keeps, so that the tiles of the previous
+	// zoom level, and the lower zoom tiles drawn while others load, are not removed by the visible tiles.
+	tileLoaderScreens = 3
+)
+
+// tileLoader fetches tiles in the background using a limited number of workers.
+// Loaded tiles are kept in memory so that they can be drawn without waiting.
+type tileLoader struct {
+	provider MapTileProvider
+	loaded   *MemoryTileCache
+	onLoad   func()
+
+	lock    sync.Mutex
+	queue   []MapTileKey
+	pending map[MapTileKey]*tileRequest // tiles that are queued or being loaded
+	workers int
+}
+
+type tileRequest struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newTileLoader(p MapTileProvider, onLoad func()) *tileLoader {
+	return &tileLoader{provider: p, onLoad: onLoad, loaded: NewMemoryTileCache(defaultTileCacheSize),
+		pending: make(map[MapTileKey]*tileRequest)}
+}
+
+// reserve makes sure that the loaded tiles can hold a few screens of visible tiles.
+// Each tile layer has its own loader, so the memory used grows with the number of layers.
+func (l *tileLoader) reserve(visible int) {
+	l.loaded.grow(visible * tileLoaderScreens)
+}
+
+// request asks for a tile to be loaded in the background, if it is not already loaded or queued.
+func (l *tileLoader) request(key MapTileKey) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if _, ok := l.pending[key]; ok {
+		return
+	}
+	if tile, ok := l.loaded.Get(key); ok && (tile.decoded() != nil || !tile.Expired()) {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l.pending[key] = &tileRequest{ctx: ctx, cancel: cancel}
+	l.queue = append(l.queue, key)
+	if l.workers < tileLoaderWorkers {
+		l.workers++
+		go l.work()
+	}
+}
+
+// retain cancels any pending requests for tiles that are no longer wanted.
+func (l *tileLoader) retain(wanted map[MapTileKey]bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	queue := l.queue[:0]
+	for _, key := range l.queue {
+		if wanted[key] {
+			queue = append(queue, key)
+		}
+	}
+	l.queue = queue
+
+	for key, req := range l.pending {
+		if !wanted[key] {
+			req.cancel()
+			delete(l.pending, key)
+		}
+	}
+}
+
+// tile returns the image for a tile if it has been loaded.
+// If the tile could not be loaded recently then failed will be true.
+func (l *tileLoader) tile(key MapTileKey) (img image.Image, failed bool) {
+	tile, ok := l.loaded.Get(key)
+	if !ok {
+		return nil, false
+	}
+	img = tile.decoded()
+	return img, img == nil && !tile.Expired()
+}
+
+func (l *tileLoader) work() {
+	for {
+		l.lock.Lock()
+		if len(l.queue) == 0 {
+			l.workers--
+			l.lock.Unlock()
+			return
+		}
+		key := l.queue[0]
+		l.queue = l.queue[1:]
+		req := l.pending[key]
+		l.lock.Unlock()
+
+		img, err := l.provider.Tile(req.ctx, key.Zoom, key.X, key.Y)
+		l.lock.Lock()
+		if l.pending[key] == req {
+			delete(l.pending, key)
+		}
+		l.lock.Unlock()
+		if req.ctx.Err() != nil || errors.Is(err, context.Canceled) {
+			continue // no longer wanted
+		}
+		req.cancel()
+
+		if err != nil {
+			fyne.LogError("tile fetch error", err)
+			l.loaded.Put(key, &MapTile{Expires: time.Now().Add(tileRetryDelay)})
+		} else {
+			l.loaded.Put(key, &MapTile{img: img})
+		}
+		if l.onLoad != nil {
+			l.onLoad()
+		}
+	}
+}
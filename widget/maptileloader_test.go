@@ -0,0 +1,275 @@
+package widget
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/theme"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testTileProvider returns tiles coloured by zoom level, blocking until released if block is set.
+type testTileProvider struct {
+	block chan struct{}
+
+	lock              sync.Mutex
+	active, maxActive int
+	cancelled         int
+}
+
+func (p *testTileProvider) Tile(ctx context.Context, zoom, x, y int) (image.Image, error) {
+	p.lock.Lock()
+	block := p.block
+	p.active++
+	if p.active > p.maxActive {
+		p.maxActive = p.active
+	}
+	p.lock.Unlock()
+	defer func() {
+		p.lock.Lock()
+		p.active--
+		p.lock.Unlock()
+	}()
+
+	if block != nil {
+		select {
+		case <-block:
+		case <-ctx.Done():
+			p.lock.Lock()
+			p.cancelled++
+			p.lock.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+
+	return testTileImage(tileSize, testTileColor(zoom)), nil
+}
+
+func testTileImage(size int, c color.Color) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+func testTileColor(zoom int) color.Color {
+	return color.NRGBA{R: uint8(zoom * 10), G: 0x80, B: 0x80, A: 0xff}
+}
+
+func waitForTile(t *testing.T, l *tileLoader, key MapTileKey) {
+	assert.Eventually(t, func() bool {
+		img, _ := l.tile(key)
+		return img != nil
+	}, time.Second, time.Millisecond)
+}
+
+func TestTileLoader_Limit(t *testing.T) {
+	p := &testTileProvider{block: make(chan struct{})}
+	l := newTileLoader(p, nil)
+	wanted := make(map[MapTileKey]bool)
+	for x := 0; x < 10; x++ {
+		key := MapTileKey{Zoom: 4, X: x}
+		wanted[key] = true
+		l.request(key)
+	}
+	assert.Eventually(t, func() bool {
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		return p.active == tileLoaderWorkers
+	}, time.Second, time.Millisecond)
+
+	close(p.block)
+	for key := range wanted {
+		waitForTile(t, l, key)
+	}
+	assert.Equal(t, tileLoaderWorkers, p.maxActive)
+}
+
+func TestTileLoader_SharedCache(t *testing.T) {
+	// both loaders decode the same cached tiles, which must be safe when run with -race
+	source := "https://tiles.example.com/%d/%d/%d.png"
+	cache := NewMemoryTileCache(100)
+	data := testTileData(t)
+	var keys []MapTileKey
+	for x := 0; x < 16; x++ {
+		keys = append(keys, MapTileKey{Zoom: 4, X: x})
+		cache.Put(MapTileKey{Source: source, Zoom: 4, X: x}, &MapTile{Data: data})
+	}
+
+	var loaders []*tileLoader
+	for i := 0; i < 2; i++ {
+		loaders = append(loaders, newTileLoader(&httpTileProvider{source: source, client: http.DefaultClient, cache: cache}, nil))
+	}
+	for _, key := range keys {
+		for _, l := range loaders {
+			l.request(key)
+		}
+	}
+	for _, key := range keys {
+		for _, l := range loaders {
+			waitForTile(t, l, key)
+		}
+		img, _ := loaders[0].tile(key)
+		cached, _ := cache.Get(MapTileKey{Source: source, Zoom: key.Zoom, X: key.X})
+		assert.Equal(t, cached.decoded(), img)
+	}
+}
+
+func TestTileLoader_Cancel(t *testing.T) {
+	p := &testTileProvider{block: make(chan struct{})}
+	l := newTileLoader(p, nil)
+	for x := 0; x < 6; x++ {
+		l.request(MapTileKey{Zoom: 4, X: x})
+	}
+	assert.Eventually(t, func() bool {
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		return p.active == tileLoaderWorkers
+	}, time.Second, time.Millisecond)
+
+	l.retain(map[MapTileKey]bool{{Zoom: 4, X: 5}: true})
+	assert.Eventually(t, func() bool {
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		return p.cancelled == tileLoaderWorkers
+	}, time.Second, time.Millisecond)
+
+	close(p.block)
+	waitForTile(t, l, MapTileKey{Zoom: 4, X: 5})
+	img, _ := l.tile(MapTileKey{Zoom: 4, X: 0})
+	assert.Nil(t, img)
+}
+
+func TestMap_DrawAsync(t *testing.T) {
+	test.NewTempApp(t)
+	p := &testTileProvider{block: make(chan struct{})}
+	m := NewMapWithOptions(WithTileProvider(p))
+	m.Resize(fyne.NewSize(256, 256))
+	m.Zoom(2)
+
+	loading := m.draw(256, 256)
+	assert.Equal(t, theme.Color(theme.ColorNameInputBackground), loading.At(128, 128))
+
+	close(p.block)
+	waitForTile(t, m.base.loader, MapTileKey{Zoom: 2, X: 1, Y: 1})
+	waitForTile(t, m.base.loader, MapTileKey{Zoom: 2, X: 2, Y: 2})
+	loaded := m.draw(256, 256)
+	assert.Equal(t, testTileColor(2), loaded.At(128, 128))
+
+	// while the next level loads the previous one is scaled up
+	block := make(chan struct{})
+	p.lock.Lock()
+	p.block = block
+	p.lock.Unlock()
+	m.ZoomIn()
+	zooming := m.draw(256, 256)
+	assert.Equal(t, testTileColor(2), zooming.At(128, 128))
+	close(block)
+}
+
+func TestMap_DrawReservesTiles(t *testing.T) {
+	test.NewTempApp(t)
+	p := &testTileProvider{block: make(chan struct{})}
+	defer close(p.block)
+	m := NewMapWithOptions(WithTileProvider(p))
+	m.Resize(fyne.NewSize(3840, 2160))
+	m.SetZoomLevel(9.6)
+
+	m.draw(3840, 2160) // a 4K screen zoomed out between levels shows more tiles than the default cache holds
+	assert.Greater(t, m.base.loader.loaded.max, defaultTileCacheSize)
+	assert.GreaterOrEqual(t, m.base.loader.loaded.max, len(m.base.loader.pending)*tileLoaderScreens)
+}
+
+// testLargeTileProvider returns 512 pixel tiles with a different colour in each quarter.
+type testLargeTileProvider struct {
+	lock  sync.Mutex
+	zooms map[int]bool
+}
+
+func (p *testLargeTileProvider) Tile(_ context.Context, zoom, x, y int) (image.Image, error) {
+	p.lock.Lock()
+	p.zooms[zoom] = true
+	p.lock.Unlock()
+
+	img := image.NewNRGBA(image.Rect(0, 0, 512, 512))
+	for i, c := range []color.NRGBA{{R: 0xff, A: 0xff}, {G: 0xff, A: 0xff}, {B: 0xff, A: 0xff}, {R: 0xff, G: 0xff, A: 0xff}} {
+		quarter := image.Rect(i%2*256, i/2*256, i%2*256+256, i/2*256+256)
+		draw.Draw(img, quarter, image.NewUniform(c), image.Point{}, draw.Src)
+	}
+	return img, nil
+}
+
+func TestMap_DrawLargeTiles(t *testing.T) {
+	test.NewTempApp(t)
+	p := &testLargeTileProvider{zooms: make(map[int]bool)}
+	m := NewMapWithOptions(WithTileProvider(p), WithTileSize(512))
+	m.Resize(fyne.NewSize(256, 256))
+	m.Zoom(1)
+
+	m.draw(512, 512) // a double density screen
+	waitForTile(t, m.base.loader, MapTileKey{Zoom: 0})
+	img := m.draw(512, 512)
+	assert.Equal(t, map[int]bool{0: true}, p.zooms) // zoom level 1 is made from the zoom level 0 tile
+
+	// each 256 unit tile is a quarter of the 512 pixel tile, drawn without scaling
+	center := m.getPosFromLatLon(0, 0)
+	assert.Equal(t, color.NRGBA{R: 0xff, A: 0xff}, img.At(int(center.X*2)-10, int(center.Y*2)-10))
+	assert.Equal(t, color.NRGBA{G: 0xff, A: 0xff}, img.At(int(center.X*2)+10, int(center.Y*2)-10))
+	assert.Equal(t, color.NRGBA{B: 0xff, A: 0xff}, img.At(int(center.X*2)-10, int(center.Y*2)+10))
+	assert.Equal(t, color.NRGBA{R: 0xff, G: 0xff, A: 0xff}, img.At(int(center.X*2)+10, int(center.Y*2)+10))
+}
+
+func TestMap_HiDPITileSource(t *testing.T) {
+	test.NewTempApp(t)
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	m := NewMapWithOptions(WithTileSource(server.URL+"/%d/%d/%d.png"),
+		WithHiDPITileSource(server.URL+"/%d/%d/%d@2x.png"))
+	m.Resize(fyne.NewSize(256, 256))
+
+	m.draw(256, 256)
+	assert.False(t, m.base.loaderHiDPI)
+	assert.Equal(t, server.URL+"/%d/%d/%d.png", m.base.loader.provider.(*httpTileProvider).source)
+
+	m.draw(512, 512)
+	assert.True(t, m.base.loaderHiDPI)
+	assert.Equal(t, server.URL+"/%d/%d/%d@2x.png", m.base.loader.provider.(*httpTileProvider).source)
+	m.base.loader.retain(nil)
+}
+
+// testErrorTileProvider fails to load any tile.
+type testErrorTileProvider struct{}
+
+func (testErrorTileProvider) Tile(context.Context, int, int, int) (image.Image, error) {
+	return nil, errors.New("tile server unavailable")
+}
+
+func TestMap_DrawErrorTile(t *testing.T) {
+	test.NewTempApp(t)
+	m := NewMapWithOptions(WithTileProvider(testErrorTileProvider{}))
+	m.Resize(fyne.NewSize(256, 256))
+
+	m.draw(256, 256)
+	assert.Eventually(t, func() bool {
+		_, failed := m.base.loader.tile(MapTileKey{})
+		return failed
+	}, time.Second, time.Millisecond)
+
+	// the middle of the tile has a cross in the error colour
+	img := m.draw(256, 256)
+	center := m.getPosFromLatLon(0, 0)
+	r, g, b, _ := img.At(int(center.X), int(center.Y)).RGBA()
+	er, eg, eb, _ := theme.Color(theme.ColorNameError).RGBA()
+	assert.Equal(t, []uint32{er, eg, eb}, []uint32{r, g, b})
+	assert.Equal(t, theme.Color(theme.ColorNameInputBackground), img.At(int(center.X)+60, int(center.Y)))
+}
@@ -0,0 +1,49 @@
+package widget
+
+import (
+	"context"
+	"image"
+	"net/http"
+)
+
+// MapTileProvider supplies the tile images that a Map draws.
+// Implementations must be safe for concurrent use.
+type MapTileProvider interface {
+	// Tile returns the image for the tile at a zoom level and x, y tile position.
+	// The tile numbering follows the XYZ scheme used by OpenStreetMap, with 0, 0 at the top left.
+	Tile(ctx context.Context, zoom, x, y int) (image.Image, error)
+}
+
+// WithTileProvider configures the map to load its tiles from the provided tile provider.
+// This replaces any tile source set using WithOsmTiles or WithTileSource.
+func WithTileProvider(p MapTileProvider) MapOption {
+	return func(m *Map) {
+		m.provider = p
+		m.resetBaseLayer()
+	}
+}
+
+// NewHTTPTileProvider returns a tile provider that downloads tiles from a URL such as
+// "https://tile.openstreetmap.org/%d/%d/%d.png", where the parameters are zoom, x and y.
+// The source can also be a template such as "https://{s}.tile.example.com/{z}/{x}/{-y}.png",
+// see WithTileSource for the supported placeholders.
+// If client is nil the default HTTP client is used, and if cache is nil tiles are kept in memory.
+func NewHTTPTileProvider(source string, client *http.Client, cache MapTileCache) MapTileProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if cache == nil {
+		cache = NewMemoryTileCache(defaultTileCacheSize)
+	}
+	return &httpTileProvider{source: source, client: client, cache: cache}
+}
+
+type httpTileProvider struct {
+	source string
+	client *http.Client
+	cache  MapTileCache
+}
+
+func (p *httpTileProvider) Tile(ctx context.Context, zoom, x, y int) (image.Image, error) {
+	return getTile(ctx, p.source, x, y, zoom, p.client, p.cache)
+}
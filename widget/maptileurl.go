@@ -0,0 +1,139 @@
+package widget
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// NewWMTSTileProvider returns a tile provider for a WMTS server using key-value GetTile requests.
+// The tile matrix set must use the web mercator tiling with matrices named by their zoom level,
+// such as "GoogleMapsCompatible". For other naming schemes use a URL template with NewHTTPTileProvider.
+// If client is nil the default HTTP client is used, and if cache is nil tiles are kept in memory.
+func NewWMTSTileProvider(endpoint, layer, tileMatrixSet, format string, client *http.Client, cache MapTileCache) MapTileProvider {
+	params := url.Values{
+		"SERVICE":       {"WMTS"},
+		"REQUEST":       {"GetTile"},
+		"VERSION":       {"1.0.0"},
+		"LAYER":         {layer},
+		"STYLE":         {"default"},
+		"TILEMATRIXSET": {tileMatrixSet},
+		"FORMAT":        {format},
+	}
+	return NewHTTPTileProvider(withQuery(endpoint, params.Encode()+"&TILEMATRIX={z}&TILEROW={y}&TILECOL={x}"),
+		client, cache)
+}
+
+// NewWMSTileProvider returns a tile provider that requests each tile from a WMS server using GetMap.
+// The layers are a comma separated list of layer names, and format is an image type such as "image/png".
+// If client is nil the default HTTP client is used, and if cache is nil tiles are kept in memory.
+func NewWMSTileProvider(endpoint, layers, format string, client *http.Client, cache MapTileCache) MapTileProvider {
+	params := url.Values{
+		"SERVICE":     {"WMS"},
+		"REQUEST":     {"GetMap"},
+		"VERSION":     {"1.3.0"},
+		"LAYERS":      {layers},
+		"STYLES":      {""},
+		"CRS":         {"EPSG:3857"},
+		"WIDTH":       {strconv.Itoa(tileSize)},
+		"HEIGHT":      {strconv.Itoa(tileSize)},
+		"FORMAT":      {format},
+		"TRANSPARENT": {"true"},
+	}
+	return NewHTTPTileProvider(withQuery(endpoint, params.Encode()+"&BBOX={bbox-epsg-3857}"), client, cache)
+}
+
+// tileURL returns the address of a tile from a tile source, as described by WithTileSource.
+func tileURL(source string, zoom, x, y int) string {
+	if strings.Contains(source, "%d") {
+		return fmt.Sprintf(source, zoom, x, y)
+	}
+
+	var out strings.Builder
+	for {
+		start := strings.IndexByte(source, '{')
+		end := strings.IndexByte(source[start+1:], '}') + start + 1
+		if start < 0 || end <= start {
+			out.WriteString(source)
+			return out.String()
+		}
+
+		out.WriteString(source[:start])
+		name := source[start+1 : end]
+		if value, ok := tileURLValue(name, zoom, x, y); ok {
+			out.WriteString(value)
+		} else {
+			out.WriteString(source[start : end+1])
+		}
+		source = source[end+1:]
+	}
+}
+
+func tileURLValue(name string, zoom, x, y int) (string, bool) {
+	switch name {
+	case "z", "TileMatrix":
+		return strconv.Itoa(zoom), true
+	case "x", "TileCol":
+		return strconv.Itoa(x), true
+	case "y", "TileRow":
+		return strconv.Itoa(y), true
+	case "-y":
+		return strconv.Itoa(1<<zoom - 1 - y), true
+	case "q", "quadkey":
+		return quadkey(zoom, x, y), true
+	case "s":
+		return subdomain('a', 'c', x, y), true
+	case "bbox-epsg-3857":
+		return mercatorBounds(zoom, x, y), true
+	}
+
+	if len(name) == 3 && name[1] == '-' && name[0] < name[2] {
+		return subdomain(name[0], name[2], x, y), true
+	}
+	return "", false
+}
+
+// quadkey returns the Bing maps identifier for a tile, where each digit picks a quarter of the previous level.
+// https://learn.microsoft.com/bingmaps/articles/bing-maps-tile-system
+func quadkey(zoom, x, y int) string {
+	key := make([]byte, zoom)
+	for i := zoom; i > 0; i-- {
+		digit := byte('0')
+		mask := 1 << (i - 1)
+		if x&mask != 0 {
+			digit++
+		}
+		if y&mask != 0 {
+			digit += 2
+		}
+		key[zoom-i] = digit
+	}
+	return string(key)
+}
+
+// subdomain picks one of a range of characters so that requests for neighbouring tiles are spread across servers.
+func subdomain(first, last byte, x, y int) string {
+	n := int(last-first) + 1
+	return string([]byte{first + byte((x+y)%n)})
+}
+
+// mercatorBounds returns the area of a tile as "minX,minY,maxX,maxY" in web mercator metres.
+func mercatorBounds(zoom, x, y int) string {
+	size := earthCircumference / math.Exp2(float64(zoom))
+	minX := -earthCircumference/2 + float64(x)*size
+	maxY := earthCircumference/2 - float64(y)*size
+	return fmt.Sprintf("%f,%f,%f,%f", minX, maxY-size, minX+size, maxY)
+}
+
+func withQuery(endpoint, query string) string {
+	switch {
+	case !strings.Contains(endpoint, "?"):
+		return endpoint + "?" + query
+	case strings.HasSuffix(endpoint, "?"), strings.HasSuffix(endpoint, "&"):
+		return endpoint + query
+	}
+	return endpoint + "&" + query
+}
@@ -0,0 +1,63 @@
+package widget
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTileURL(t *testing.T) {
+	assert.Equal(t, "https://tile.openstreetmap.org/3/2/1.png",
+		tileURL("https://tile.openstreetmap.org/%d/%d/%d.png", 3, 2, 1))
+	assert.Equal(t, "https://tile.openstreetmap.org/3/2/1.png",
+		tileURL("https://tile.openstreetmap.org/{z}/{x}/{y}.png", 3, 2, 1))
+	assert.Equal(t, "https://example.com/tms/3/2/6.png", tileURL("https://example.com/tms/{z}/{x}/{-y}.png", 3, 2, 1))
+	assert.Equal(t, "https://example.com/tiles/{unknown}/3", tileURL("https://example.com/tiles/{unknown}/{z}", 3, 2, 1))
+	assert.Equal(t, "https://example.com/{z", tileURL("https://example.com/{z", 3, 2, 1))
+}
+
+func TestTileURL_Subdomains(t *testing.T) {
+	assert.Equal(t, "https://a.tile.example.com/0/0/0.png", tileURL("https://{s}.tile.example.com/{z}/{x}/{y}.png", 0, 0, 0))
+	assert.Equal(t, "https://b.tile.example.com/1/1/0.png", tileURL("https://{s}.tile.example.com/{z}/{x}/{y}.png", 1, 1, 0))
+	assert.Equal(t, "https://c.tile.example.com/1/1/1.png", tileURL("https://{s}.tile.example.com/{z}/{x}/{y}.png", 1, 1, 1))
+	assert.Equal(t, "https://t4.example.com/2", tileURL("https://t{1-4}.example.com/{z}", 2, 1, 2))
+}
+
+func TestTileURL_Quadkey(t *testing.T) {
+	// the example from https://learn.microsoft.com/bingmaps/articles/bing-maps-tile-system
+	assert.Equal(t, "https://example.com/tiles/a213.jpeg", tileURL("https://example.com/tiles/a{q}.jpeg", 3, 3, 5))
+	assert.Equal(t, "https://example.com/tiles/a213.jpeg", tileURL("https://example.com/tiles/a{quadkey}.jpeg", 3, 3, 5))
+	assert.Equal(t, "https://example.com/tiles/a.jpeg", tileURL("https://example.com/tiles/a{q}.jpeg", 0, 0, 0))
+}
+
+func TestNewWMTSTileProvider(t *testing.T) {
+	p := NewWMTSTileProvider("https://example.com/wmts", "roads", "GoogleMapsCompatible", "image/png", nil, nil)
+	u, err := url.Parse(tileURL(p.(*httpTileProvider).source, 5, 10, 12))
+	assert.NoError(t, err)
+
+	query := u.Query()
+	assert.Equal(t, "GetTile", query.Get("REQUEST"))
+	assert.Equal(t, "roads", query.Get("LAYER"))
+	assert.Equal(t, "image/png", query.Get("FORMAT"))
+	assert.Equal(t, "5", query.Get("TILEMATRIX"))
+	assert.Equal(t, "12", query.Get("TILEROW"))
+	assert.Equal(t, "10", query.Get("TILECOL"))
+}
+
+func TestNewWMSTileProvider(t *testing.T) {
+	p := NewWMSTileProvider("https://example.com/wms?map=world", "borders,rivers", "image/png", nil, nil)
+	source := p.(*httpTileProvider).source
+	assert.True(t, strings.HasPrefix(source, "https://example.com/wms?map=world&"))
+
+	u, err := url.Parse(tileURL(source, 1, 1, 0))
+	assert.NoError(t, err)
+	query := u.Query()
+	assert.Equal(t, "GetMap", query.Get("REQUEST"))
+	assert.Equal(t, "borders,rivers", query.Get("LAYERS"))
+	assert.Equal(t, "EPSG:3857", query.Get("CRS"))
+	assert.Equal(t, "256", query.Get("WIDTH"))
+	// the north east quarter of the world
+	assert.Equal(t, "0.000000,0.000000,20037508.343000,20037508.343000", query.Get("BBOX"))
+}